@@ -0,0 +1,125 @@
+package mlflow
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+// cleanArtifactPath makes artifactPath relative to the root of a repo, so that it can't refer to
+// anything outside of it. The root is "".
+func cleanArtifactPath(artifactPath string) string {
+	return path.Clean("/" + artifactPath)[1:]
+}
+
+// DownloadArtifacts implements [ArtifactRepo.DownloadArtifacts] with the repo's ListArtifacts and OpenArtifact,
+// for use by implementations outside of this package.
+// Based on
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/store/artifact/artifact_repo.py#L181
+func DownloadArtifacts(repo ArtifactRepo, artifactPath, dstDir string) (string, error) {
+	artifactPath = cleanArtifactPath(artifactPath)
+	localPath := filepath.Join(dstDir, filepath.FromSlash(artifactPath))
+	entries, err := repo.ListArtifacts(artifactPath)
+	if err != nil {
+		return "", err
+	}
+	// Listing a file, a missing path or an empty directory returns nothing, so check the parent's listing.
+	if len(entries) == 0 && artifactPath != "" {
+		isDir, err := isArtifactDir(repo, artifactPath)
+		if err != nil {
+			return "", err
+		}
+		if !isDir {
+			return localPath, downloadArtifactFile(repo, artifactPath, localPath)
+		}
+	}
+	if err := os.MkdirAll(localPath, 0755); err != nil {
+		return "", err
+	}
+	return localPath, downloadArtifactEntries(repo, entries, dstDir)
+}
+
+// isArtifactDir returns whether the parent of artifactPath lists it as a directory.
+func isArtifactDir(repo ArtifactRepo, artifactPath string) (bool, error) {
+	parent := path.Dir(artifactPath)
+	if parent == "." {
+		parent = ""
+	}
+	siblings, err := repo.ListArtifacts(parent)
+	if err != nil {
+		return false, err
+	}
+	for _, sibling := range siblings {
+		if sibling.Path == artifactPath {
+			return sibling.IsDir, nil
+		}
+	}
+	return false, nil
+}
+
+// downloadArtifactEntries downloads entries, which were listed by repo, and the trees under them to dstDir.
+func downloadArtifactEntries(repo ArtifactRepo, entries []ArtifactInfo, dstDir string) error {
+	for _, entry := range entries {
+		localPath := filepath.Join(dstDir, filepath.FromSlash(entry.Path))
+		if !entry.IsDir {
+			if err := downloadArtifactFile(repo, entry.Path, localPath); err != nil {
+				return err
+			}
+			continue
+		}
+		children, err := repo.ListArtifacts(entry.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(localPath, 0755); err != nil {
+			return err
+		}
+		if err := downloadArtifactEntries(repo, children, dstDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadArtifactFile downloads the file at artifactPath to localPath. The file is written under a
+// temporary name and then renamed, so that an interrupted download doesn't leave a truncated file.
+func downloadArtifactFile(repo ArtifactRepo, artifactPath, localPath string) (err error) {
+	src, err := repo.OpenArtifact(artifactPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return err
+	}
+	dst, err := os.CreateTemp(filepath.Dir(localPath), "."+filepath.Base(localPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(dst.Name())
+		}
+	}()
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("failed to download artifact %s: %w", artifactPath, err)
+	}
+	// CreateTemp only gives the owner access.
+	if err := dst.Chmod(0644); err != nil {
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(dst.Name(), localPath)
+}
+
+// notFoundArtifact returns an error matching [ErrNotFound] for the artifact at artifactPath.
+func notFoundArtifact(artifactPath string) error {
+	return newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no artifact at %s", artifactPath)
+}
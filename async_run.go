@@ -0,0 +1,257 @@
+package mlflow
+
+import (
+	"sync"
+	"time"
+)
+
+// AsyncRun wraps a [Run] so that metrics, params and tags are buffered in memory and logged
+// in batches by a background goroutine, rather than with a request per call.
+// A batch is logged when the flush interval has passed or the batch size is reached,
+// and when Flush, End or Fail is called.
+//
+// Errors from background logging are passed to the handler set with [WithErrorHandler].
+// If there is no handler, the first error is returned by the next call to a logging method,
+// Flush, End, Fail or SetTerminated. Values in a batch that failed to be logged are dropped.
+//
+// Methods other than the logging methods are passed through to the wrapped run, so for example
+// GetTag only sees tags that have been flushed. WithContext returns a copy of the wrapped run
+// that logs synchronously. An AsyncRun must not be used after End or Fail.
+type AsyncRun struct {
+	Run
+	options asyncOptions
+
+	mtx     sync.Mutex
+	metrics []LoggedMetric
+	params  []Param
+	tags    []Tag
+	// The first error from background logging, if there is no error handler.
+	err error
+
+	// Held while logging a batch, so that batches are logged in order.
+	flushMtx sync.Mutex
+	wake     chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// AsyncOption configures an [AsyncRun] created by [NewAsyncRun].
+type AsyncOption func(*asyncOptions)
+
+type asyncOptions struct {
+	flushInterval time.Duration
+	batchSize     int
+	onError       func(error)
+}
+
+// defaultFlushInterval is the default for [WithFlushInterval].
+const defaultFlushInterval = 5 * time.Second
+
+// WithFlushInterval sets how often buffered values are logged. Defaults to 5 seconds,
+// which is also used if interval is not positive.
+func WithFlushInterval(interval time.Duration) AsyncOption {
+	return func(o *asyncOptions) {
+		o.flushInterval = interval
+	}
+}
+
+// WithBatchSize sets the number of buffered metrics, params and tags that triggers logging
+// before the flush interval has passed. Defaults to 1000, the most that fits in one request,
+// which is also used if n is not positive.
+func WithBatchSize(n int) AsyncOption {
+	return func(o *asyncOptions) {
+		o.batchSize = n
+	}
+}
+
+// WithErrorHandler sets a function to call with errors from background logging.
+// It is called from the background goroutine, so it must not call methods of the AsyncRun.
+func WithErrorHandler(onError func(error)) AsyncOption {
+	return func(o *asyncOptions) {
+		o.onError = onError
+	}
+}
+
+// NewAsyncRun starts logging to run in the background. Call End or Fail to stop.
+func NewAsyncRun(run Run, opts ...AsyncOption) *AsyncRun {
+	options := asyncOptions{flushInterval: defaultFlushInterval, batchSize: maxEntitiesPerBatch}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.flushInterval <= 0 {
+		options.flushInterval = defaultFlushInterval
+	}
+	if options.batchSize <= 0 {
+		options.batchSize = maxEntitiesPerBatch
+	}
+	a := &AsyncRun{
+		Run:     run,
+		options: options,
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go a.loop()
+	return a
+}
+
+func (a *AsyncRun) loop() {
+	defer close(a.stopped)
+	ticker := time.NewTicker(a.options.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+		case <-a.wake:
+		}
+		if err := a.flush(); err != nil {
+			a.report(err)
+		}
+	}
+}
+
+// add buffers values to be logged and returns any unreported error.
+func (a *AsyncRun) add(metrics []LoggedMetric, params []Param, tags []Tag) error {
+	a.mtx.Lock()
+	a.metrics = append(a.metrics, metrics...)
+	a.params = append(a.params, params...)
+	a.tags = append(a.tags, tags...)
+	full := len(a.metrics)+len(a.params)+len(a.tags) >= a.options.batchSize
+	err := a.err
+	a.err = nil
+	a.mtx.Unlock()
+	if full {
+		select {
+		case a.wake <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+func (a *AsyncRun) report(err error) {
+	if a.options.onError != nil {
+		a.options.onError(err)
+		return
+	}
+	a.mtx.Lock()
+	if a.err == nil {
+		a.err = err
+	}
+	a.mtx.Unlock()
+}
+
+// takeErr returns and clears the unreported error.
+func (a *AsyncRun) takeErr() error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	err := a.err
+	a.err = nil
+	return err
+}
+
+// flush logs everything that has been buffered.
+func (a *AsyncRun) flush() error {
+	a.flushMtx.Lock()
+	defer a.flushMtx.Unlock()
+	a.mtx.Lock()
+	metrics, params, tags := a.metrics, a.params, a.tags
+	a.metrics, a.params, a.tags = nil, nil, nil
+	a.mtx.Unlock()
+	if len(metrics)+len(params)+len(tags) == 0 {
+		return nil
+	}
+	return a.Run.LogBatch(metrics, params, tags)
+}
+
+// Flush logs everything that has been buffered, and waits for it to complete.
+func (a *AsyncRun) Flush() error {
+	err := a.flush()
+	if pending := a.takeErr(); pending != nil {
+		return pending
+	}
+	return err
+}
+
+func (a *AsyncRun) LogMetric(key string, val float64, step int64) error {
+	return a.add([]LoggedMetric{{Key: key, Val: val, Step: step, Timestamp: time.Now()}}, nil, nil)
+}
+
+func (a *AsyncRun) LogMetrics(metrics []Metric, step int64) error {
+	now := time.Now()
+	logged := make([]LoggedMetric, len(metrics))
+	for i, m := range metrics {
+		logged[i] = LoggedMetric{Key: m.Key, Val: m.Val, Step: step, Timestamp: now}
+	}
+	return a.add(logged, nil, nil)
+}
+
+// Implements [Run.LogBatch].
+func (a *AsyncRun) LogBatch(metrics []LoggedMetric, params []Param, tags []Tag) error {
+	// Set timestamps now rather than when the batch is logged.
+	now := time.Now()
+	logged := make([]LoggedMetric, len(metrics))
+	for i, m := range metrics {
+		if m.Timestamp.IsZero() {
+			m.Timestamp = now
+		}
+		logged[i] = m
+	}
+	return a.add(logged, params, tags)
+}
+
+func (a *AsyncRun) LogParam(key, value string) error {
+	return a.add(nil, []Param{{Key: key, Val: value}}, nil)
+}
+
+func (a *AsyncRun) LogParams(params []Param) error {
+	return a.add(nil, params, nil)
+}
+
+func (a *AsyncRun) SetTag(key, value string) error {
+	return a.add(nil, nil, []Tag{{Key: key, Val: value}})
+}
+
+func (a *AsyncRun) SetTags(tags []Tag) error {
+	return a.add(nil, nil, tags)
+}
+
+// close stops the background goroutine and logs everything that has been buffered.
+func (a *AsyncRun) close() error {
+	a.stopOnce.Do(func() { close(a.stop) })
+	<-a.stopped
+	return a.Flush()
+}
+
+// End logs everything that has been buffered, then ends the run.
+// Like Fail, the run is ended even if logging fails.
+func (a *AsyncRun) End() error {
+	err := a.close()
+	if endErr := a.Run.End(); endErr != nil {
+		return endErr
+	}
+	return err
+}
+
+// Fail logs everything that has been buffered, then marks the run as failed.
+// The run is marked as failed even if logging fails.
+func (a *AsyncRun) Fail() error {
+	err := a.close()
+	if failErr := a.Run.Fail(); failErr != nil {
+		return failErr
+	}
+	return err
+}
+
+// SetTerminated logs everything that has been buffered, then sets the status and end time of the run.
+// Like Fail, the status is set even if logging fails.
+func (a *AsyncRun) SetTerminated(status RunStatus, endTime time.Time) error {
+	err := a.close()
+	if setErr := a.Run.SetTerminated(status, endTime); setErr != nil {
+		return setErr
+	}
+	return err
+}
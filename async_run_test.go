@@ -0,0 +1,113 @@
+package mlflow
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRun(t *testing.T) Run {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	return run
+}
+
+func TestAsyncRun(t *testing.T) {
+	run := newTestRun(t)
+	async := NewAsyncRun(run, WithFlushInterval(time.Hour))
+	require.NoError(t, async.LogMetric("loss", 1, 0))
+	require.NoError(t, async.LogMetrics([]Metric{{Key: "loss", Val: 0.5}, {Key: "acc", Val: 0.9}}, 1))
+	require.NoError(t, async.LogParam("lr", "0.1"))
+	require.NoError(t, async.SetTag("tag0", "val0"))
+	_, err := run.GetTag("tag0")
+	assert.ErrorIs(t, err, ErrNotFound, "nothing should be logged before flushing")
+
+	require.NoError(t, async.Flush())
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, int64(1), history[1].Step)
+	val, err := run.GetParam("lr")
+	require.NoError(t, err)
+	assert.Equal(t, "0.1", val)
+
+	// End drains the buffer.
+	require.NoError(t, async.LogMetric("loss", 0.1, 2))
+	require.NoError(t, async.End())
+	history, _, err = run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	assert.Len(t, history, 3)
+	info, err := run.Info()
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusFinished, info.Status)
+}
+
+func TestAsyncRunBatchSize(t *testing.T) {
+	run := newTestRun(t)
+	async := NewAsyncRun(run, WithFlushInterval(time.Hour), WithBatchSize(2))
+	require.NoError(t, async.LogMetric("loss", 1, 0))
+	require.NoError(t, async.LogMetric("loss", 2, 1))
+	assert.Eventually(t, func() bool {
+		history, _, err := run.GetMetricHistory("loss", "")
+		return err == nil && len(history) == 2
+	}, 5*time.Second, time.Millisecond)
+	require.NoError(t, async.End())
+}
+
+type failingRun struct {
+	Run
+}
+
+func (r failingRun) LogBatch(metrics []LoggedMetric, params []Param, tags []Tag) error {
+	return errors.New("server unavailable")
+}
+
+func TestAsyncRunErrors(t *testing.T) {
+	async := NewAsyncRun(failingRun{newTestRun(t)}, WithFlushInterval(time.Millisecond))
+	require.NoError(t, async.LogMetric("loss", 1, 0))
+	assert.Eventually(t, func() bool {
+		return async.LogMetric("loss", 1, 1) != nil
+	}, 5*time.Second, time.Millisecond)
+	assert.Error(t, async.End())
+	info, err := async.Info()
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusFinished, info.Status, "the run must be ended even if logging fails")
+
+	var mtx sync.Mutex
+	var handled []error
+	async = NewAsyncRun(failingRun{newTestRun(t)}, WithFlushInterval(time.Hour), WithErrorHandler(func(err error) {
+		mtx.Lock()
+		handled = append(handled, err)
+		mtx.Unlock()
+	}))
+	require.NoError(t, async.LogMetric("loss", 1, 0))
+	assert.Error(t, async.Flush())
+	require.NoError(t, async.LogMetric("loss", 1, 1))
+	assert.Error(t, async.Fail())
+	info, err = async.Info()
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusFailed, info.Status, "the run must be failed even if logging fails")
+	mtx.Lock()
+	assert.Empty(t, handled, "errors returned directly must not also be handled")
+	mtx.Unlock()
+}
+
+func TestAsyncRunInvalidOptions(t *testing.T) {
+	run := newTestRun(t)
+	async := NewAsyncRun(run, WithFlushInterval(0), WithBatchSize(0))
+	assert.Equal(t, defaultFlushInterval, async.options.flushInterval)
+	assert.Equal(t, maxEntitiesPerBatch, async.options.batchSize)
+	require.NoError(t, async.LogMetric("loss", 1, 0))
+	require.NoError(t, async.End())
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
@@ -0,0 +1,77 @@
+// Command gc permanently removes deleted runs and experiments from a local file store.
+// It is equivalent to `mlflow gc`.
+//
+// Usage:
+//
+//	gc [-backend-store-uri path] [-older-than duration] [-run-ids ids] [-experiment-ids ids] [-dry-run]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+)
+
+func splitIDs(ids string) []string {
+	if ids == "" {
+		return nil
+	}
+	return strings.Split(ids, ",")
+}
+
+func main() {
+	storeURI := flag.String("backend-store-uri", os.Getenv(mlflow.TrackingURIEnvName),
+		"Local file store to remove deleted runs and experiments from. Defaults to $"+mlflow.TrackingURIEnvName)
+	olderThan := flag.Duration("older-than", 0,
+		"Only remove runs and experiments deleted at least this long ago, e.g. 720h. Defaults to all")
+	runIDs := flag.String("run-ids", "", "Comma-separated IDs of deleted runs to remove. Defaults to all")
+	experimentIDs := flag.String("experiment-ids", "", "Comma-separated IDs of deleted experiments to remove. Defaults to all")
+	dryRun := flag.Bool("dry-run", false, "Report what would be removed without removing anything")
+	flag.Parse()
+
+	if *storeURI == "" {
+		log.Fatal("-backend-store-uri is required")
+	}
+	parsed, err := url.Parse(*storeURI)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if parsed.Scheme != "file" && parsed.Scheme != "" {
+		log.Fatalf("only local file stores are supported, got %s", *storeURI)
+	}
+	store, err := mlflow.NewFileStore(parsed.Path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := mlflow.GarbageCollectOptions{
+		RunIDs:        splitIDs(*runIDs),
+		ExperimentIDs: splitIDs(*experimentIDs),
+		DryRun:        *dryRun,
+	}
+	if *olderThan > 0 {
+		opts.DeletedBefore = time.Now().Add(-*olderThan)
+	}
+	result, err := store.GarbageCollect(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	verb := "Removed"
+	if *dryRun {
+		verb = "Would remove"
+	}
+	for _, id := range result.ExperimentIDs {
+		fmt.Printf("%s experiment %s\n", verb, id)
+	}
+	for _, id := range result.RunIDs {
+		fmt.Printf("%s run %s\n", verb, id)
+	}
+	fmt.Printf("%s %d experiments and %d runs, reclaiming %d bytes\n",
+		verb, len(result.ExperimentIDs), len(result.RunIDs), result.BytesReclaimed)
+}
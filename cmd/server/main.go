@@ -0,0 +1,38 @@
+// Command server runs an MLFlow tracking server, like `mlflow server` but without Python.
+// Clients access artifacts directly, so the backend store should use artifact locations that
+// clients can reach, e.g. a local file store used by clients on the same machine.
+//
+// Usage:
+//
+//	server [-backend-store-uri uri] [-host host] [-port port]
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+)
+
+func main() {
+	storeURI := flag.String("backend-store-uri", os.Getenv(mlflow.TrackingURIEnvName),
+		"Store to serve, e.g. a local file store path. Defaults to $"+mlflow.TrackingURIEnvName)
+	host := flag.String("host", "127.0.0.1", "Host to listen on")
+	port := flag.Int("port", 5000, "Port to listen on")
+	flag.Parse()
+
+	if *storeURI == "" {
+		log.Fatal("-backend-store-uri is required")
+	}
+	tracking, err := mlflow.NewTracking(*storeURI, "", log.Default())
+	if err != nil {
+		log.Fatal(err)
+	}
+	addr := net.JoinHostPort(*host, strconv.Itoa(*port))
+	log.Printf("Serving %s on http://%s", tracking.URI(), addr)
+	log.Fatal(http.ListenAndServe(addr, mlflow.NewHandler(tracking)))
+}
@@ -0,0 +1,65 @@
+// Command sync copies experiments and runs from a local file store to a tracking server.
+// It can be run repeatedly: runs that were copied before are updated rather than copied again.
+//
+// Usage:
+//
+//	sync -backend-store-uri path [-tracking-uri uri] [-experiment-ids ids]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+)
+
+func main() {
+	storeURI := flag.String("backend-store-uri", "", "Local file store to copy from")
+	trackingURI := flag.String("tracking-uri", os.Getenv(mlflow.TrackingURIEnvName),
+		"Tracking server to copy to. Defaults to $"+mlflow.TrackingURIEnvName)
+	experimentIDs := flag.String("experiment-ids", "",
+		"Comma-separated IDs of experiments in the file store to copy. Defaults to all active experiments")
+	flag.Parse()
+
+	if *storeURI == "" {
+		log.Fatal("-backend-store-uri is required")
+	}
+	if *trackingURI == "" {
+		log.Fatal("-tracking-uri is required")
+	}
+	parsed, err := url.Parse(*storeURI)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if parsed.Scheme != "file" && parsed.Scheme != "" {
+		log.Fatalf("only local file stores are supported, got %s", *storeURI)
+	}
+	src, err := mlflow.NewFileStore(parsed.Path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dst, err := mlflow.NewTracking(*trackingURI, "", log.Default())
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := mlflow.SyncOptions{}
+	if *experimentIDs != "" {
+		opts.ExperimentIDs = strings.Split(*experimentIDs, ",")
+	}
+	result, err := mlflow.Sync(src, dst, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, id := range result.CreatedRunIDs {
+		fmt.Printf("Created run %s\n", id)
+	}
+	for _, id := range result.UpdatedRunIDs {
+		fmt.Printf("Updated run %s\n", id)
+	}
+	fmt.Printf("Created %d runs and updated %d runs, logging %d metric values\n",
+		len(result.CreatedRunIDs), len(result.UpdatedRunIDs), result.MetricsLogged)
+}
@@ -18,13 +18,29 @@ runfiles = python.runfiles.runfiles.Create()
 logging.basicConfig(level=logging.INFO)
 
 
+def free_port() -> int:
+    sock = socket.socket()
+    sock.bind(("", 0))
+    port = sock.getsockname()[1]
+    sock.close()
+    return port
+
+
 class TC(unittest.TestCase):
+    def wait_for_server(self, server_uri):
+        up = False
+        for _ in range(10):
+            try:
+                up = requests.get(
+                    f"{server_uri}/api/2.0/mlflow/experiments/get?experiment_id=0"
+                )
+                break
+            except requests.exceptions.ConnectionError:
+                time.sleep(0.5)
+        self.assertTrue(up, f"server did not start: {server_uri}")
+
     def start_mlflow_server(self, root_dir) -> str:
-        # look for a free port
-        sock = socket.socket()
-        sock.bind(("", 0))
-        port = sock.getsockname()[1]
-        sock.close()
+        port = free_port()
 
         # mlflow server expects gunicorn on its PATH.
         if not shutil.which("gunicorn"):
@@ -53,22 +69,36 @@ class TC(unittest.TestCase):
             },
         )
         server_process.start()
-        # wait for server to start
         server_uri = f"http://localhost:{port}"
-        up = False
-        for _ in range(10):
-            try:
-                up = requests.get(
-                    f"{server_uri}/api/2.0/mlflow/experiments/get?experiment_id=0"
-                )
-                break
-            except requests.exceptions.ConnectionError:
-                time.sleep(0.5)
-        self.assertTrue(up, f"server did not start: {server_uri}")
+        self.wait_for_server(server_uri)
         self.assertTrue(server_process.is_alive())
         self.addCleanup(server_process.terminate)
         return server_uri
 
+    def start_go_server(self, root_dir) -> str:
+        """Starts the tracking server implemented in Go, from cmd/server."""
+        port = free_port()
+        logging.info(
+            "Starting Go server on port %d with file store %s", port, root_dir
+        )
+        server_process = subprocess.Popen(
+            (
+                runfiles.Rlocation("_main/cmd/server/server_/server"),
+                "-backend-store-uri",
+                root_dir,
+                "-host",
+                "localhost",
+                "-port",
+                str(port),
+            )
+        )
+        self.addCleanup(server_process.wait)
+        self.addCleanup(server_process.terminate)
+        server_uri = f"http://localhost:{port}"
+        self.wait_for_server(server_uri)
+        self.assertIsNone(server_process.poll())
+        return server_uri
+
     def test_conformance(self):
         for binary in (
             "_main/conformance/go_/go",
@@ -76,17 +106,19 @@ class TC(unittest.TestCase):
         ):
             lang = os.path.basename(binary)
             with self.subTest(lang=lang):
-                for start_server in (False, True):
+                for server in (None, "py", "go"):
                     root_dir = tempfile.mkdtemp()
                     # Python mlflow client fails if the directory
                     # exists but does not already contain the default experiment.
                     # Remove it so that it creates the default experiment rather than failing.
                     os.rmdir(root_dir)
-                    with self.subTest(scheme="http" if start_server else "file"):
-                        server_process = None
-                        if start_server:
+                    with self.subTest(server=server or "file"):
+                        if server == "py":
                             server_uri = self.start_mlflow_server(root_dir)
                             env = {"MLFLOW_TRACKING_URI": server_uri}
+                        elif server == "go":
+                            server_uri = self.start_go_server(root_dir)
+                            env = {"MLFLOW_TRACKING_URI": server_uri}
                         else:
                             env = {"MLFLOW_TRACKING_URI": root_dir}
                         subprocess.check_call(
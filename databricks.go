@@ -0,0 +1,136 @@
+package mlflow
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variables used by the Databricks CLI and SDKs.
+// https://docs.databricks.com/en/dev-tools/auth/index.html
+const (
+	databricksConfigFileEnvName    = "DATABRICKS_CONFIG_FILE"
+	databricksConfigProfileEnvName = "DATABRICKS_CONFIG_PROFILE"
+	databricksHostEnvName          = "DATABRICKS_HOST"
+	databricksTokenEnvName         = "DATABRICKS_TOKEN"
+	databricksClientIDEnvName      = "DATABRICKS_CLIENT_ID"
+	databricksClientSecretEnvName  = "DATABRICKS_CLIENT_SECRET"
+
+	databricksDefaultProfile = "DEFAULT"
+)
+
+// databricksConfig is a profile from the Databricks config file, ~/.databrickscfg.
+type databricksConfig struct {
+	host         string
+	token        string
+	clientID     string
+	clientSecret string
+	username     string
+	password     string
+}
+
+// readDatabricksConfig reads the named profile from the Databricks config file.
+// If profile is empty, the DATABRICKS_* environment variables are used if DATABRICKS_HOST is set,
+// otherwise the profile named by DATABRICKS_CONFIG_PROFILE, or the default profile.
+func readDatabricksConfig(profile string, getConfig func(string) string) (databricksConfig, error) {
+	if profile == "" && getConfig(databricksHostEnvName) != "" {
+		return databricksConfig{
+			host:         getConfig(databricksHostEnvName),
+			token:        getConfig(databricksTokenEnvName),
+			clientID:     getConfig(databricksClientIDEnvName),
+			clientSecret: getConfig(databricksClientSecretEnvName),
+		}, nil
+	}
+	if profile == "" {
+		profile = getConfig(databricksConfigProfileEnvName)
+	}
+	if profile == "" {
+		profile = databricksDefaultProfile
+	}
+	path := getConfig(databricksConfigFileEnvName)
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return databricksConfig{}, fmt.Errorf("failed to find the Databricks config file: %w", err)
+		}
+		path = filepath.Join(home, ".databrickscfg")
+	}
+	sections, err := readINIFile(path)
+	if err != nil {
+		return databricksConfig{}, fmt.Errorf("failed to read the Databricks config file: %w", err)
+	}
+	section, ok := sections[profile]
+	if !ok {
+		return databricksConfig{}, fmt.Errorf("profile %q not found in %s", profile, path)
+	}
+	// Like Python's configparser, which the Databricks SDK uses, the default profile's
+	// values apply to all profiles that don't override them.
+	get := func(key string) string {
+		if val, ok := section[key]; ok {
+			return val
+		}
+		return sections[databricksDefaultProfile][key]
+	}
+	config := databricksConfig{
+		host:         get("host"),
+		token:        get("token"),
+		clientID:     get("client_id"),
+		clientSecret: get("client_secret"),
+		username:     get("username"),
+		password:     get("password"),
+	}
+	if config.host == "" {
+		return databricksConfig{}, fmt.Errorf("profile %q in %s has no host", profile, path)
+	}
+	return config, nil
+}
+
+// readINIFile parses a simple INI file into a map from section name to key-value pairs.
+func readINIFile(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sections := make(map[string]map[string]string)
+	var section map[string]string
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if sections[name] == nil {
+				sections[name] = make(map[string]string)
+			}
+			section = sections[name]
+			continue
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if !ok || section == nil {
+			return nil, fmt.Errorf("%s:%d: expected a [section] or key = value", path, lineNum)
+		}
+		section[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(val)
+	}
+	return sections, scanner.Err()
+}
+
+// databricksRESTStoreOptions returns the options to authenticate as configured in the profile.
+func databricksRESTStoreOptions(config databricksConfig) ([]RESTStoreOption, error) {
+	switch {
+	case config.token != "":
+		return nil, nil
+	case config.clientID != "" && config.clientSecret != "":
+		// https://docs.databricks.com/en/dev-tools/auth/oauth-m2m.html
+		tokenURL := strings.TrimSuffix(config.host, "/") + "/oidc/v1/token"
+		return []RESTStoreOption{
+			WithOAuthClientCredentials(tokenURL, config.clientID, config.clientSecret, "all-apis")}, nil
+	case config.username != "":
+		return []RESTStoreOption{WithBasicAuth(config.username, config.password)}, nil
+	}
+	return nil, fmt.Errorf("Databricks profile for %s has no token, client_id and client_secret, or username", config.host)
+}
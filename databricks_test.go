@@ -0,0 +1,138 @@
+package mlflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeDatabricks returns a server with an OAuth token endpoint that issues tokens expiring
+// after expiresIn seconds, or without an expiry if it is 0, and an MLFlow endpoint that requires
+// one of those tokens.
+func newFakeDatabricks(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	var tokensIssued int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oidc/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, _ := r.BasicAuth()
+		if r.Method != http.MethodPost || r.FormValue("grant_type") != "client_credentials" ||
+			r.FormValue("scope") != "all-apis" || clientID != "my-client" || clientSecret != "my-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(&tokensIssued, 1)
+		res := map[string]interface{}{
+			"access_token": fmt.Sprintf("oauth-token-%d", n),
+			"token_type":   "Bearer",
+		}
+		if expiresIn > 0 {
+			res["expires_in"] = expiresIn
+		}
+		json.NewEncoder(w).Encode(res)
+	})
+	mux.HandleFunc("/api/2.0/mlflow/experiments/get", func(w http.ResponseWriter, r *http.Request) {
+		want := fmt.Sprintf("Bearer oauth-token-%d", atomic.LoadInt32(&tokensIssued))
+		if auth := r.Header.Get("Authorization"); auth != want && auth != "Bearer my-pat" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"experiment": {"experiment_id": "0", "name": "Default"}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &tokensIssued
+}
+
+func TestDatabricksOAuth(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		expiresIn  int
+		wantTokens int32
+	}{
+		{"cached", 3600, 1},
+		{"refreshed before expiry", int(oauthExpiryMargin.Seconds()) - 1, 3},
+		{"no expiry", 0, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server, tokensIssued := newFakeDatabricks(t, tc.expiresIn)
+			configPath := filepath.Join(t.TempDir(), ".databrickscfg")
+			require.NoError(t, os.WriteFile(configPath, []byte(fmt.Sprintf(`
+; Comments are ignored.
+[DEFAULT]
+host = %s
+token = my-pat
+
+[ci]
+client_id = my-client
+client_secret = my-secret
+token =
+`, server.URL)), 0600))
+			config := map[string]string{databricksConfigFileEnvName: configPath}
+			tracking, err := newTracking("databricks://ci", "", nil, func(key string) string { return config[key] })
+			require.NoError(t, err)
+			for i := 0; i < 3; i++ {
+				_, err = tracking.GetExperiment("0")
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantTokens, atomic.LoadInt32(tokensIssued))
+
+			// The default profile uses the personal access token.
+			tracking, err = newTracking("databricks", "", nil, func(key string) string { return config[key] })
+			require.NoError(t, err)
+			_, err = tracking.GetExperiment("0")
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantTokens, atomic.LoadInt32(tokensIssued))
+		})
+	}
+}
+
+// The bearer token from the environment takes precedence over the config file for both forms of URI.
+func TestDatabricksBearerTokenEnv(t *testing.T) {
+	server, tokensIssued := newFakeDatabricks(t, 3600)
+	configPath := filepath.Join(t.TempDir(), ".databrickscfg")
+	require.NoError(t, os.WriteFile(configPath, []byte(fmt.Sprintf(`
+[DEFAULT]
+host = %s
+token = stale-pat
+
+[ci]
+client_id = my-client
+client_secret = my-secret
+`, server.URL)), 0600))
+	config := map[string]string{databricksConfigFileEnvName: configPath, BearerTokenEnvName: "my-pat"}
+	for _, uri := range []string{"databricks", "databricks://ci"} {
+		tracking, err := newTracking(uri, "", nil, func(key string) string { return config[key] })
+		require.NoError(t, err)
+		_, err = tracking.GetExperiment("0")
+		assert.NoError(t, err, uri)
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(tokensIssued))
+}
+
+func TestReadDatabricksConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".databrickscfg")
+	require.NoError(t, os.WriteFile(configPath, []byte("[DEFAULT]\nhost = https://example.cloud.databricks.com\n"), 0600))
+	config := map[string]string{databricksConfigFileEnvName: configPath}
+	getConfig := func(key string) string { return config[key] }
+
+	_, err := readDatabricksConfig("missing", getConfig)
+	assert.Error(t, err)
+	dbConfig, err := readDatabricksConfig("", getConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.cloud.databricks.com", dbConfig.host)
+	_, err = databricksRESTStoreOptions(dbConfig)
+	assert.Error(t, err, "expected an error for a profile without credentials")
+
+	config[databricksHostEnvName] = "https://env.cloud.databricks.com"
+	config[databricksTokenEnvName] = "env-token"
+	dbConfig, err = readDatabricksConfig("", getConfig)
+	require.NoError(t, err)
+	assert.Equal(t, databricksConfig{host: "https://env.cloud.databricks.com", token: "env-token"}, dbConfig)
+}
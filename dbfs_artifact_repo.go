@@ -6,8 +6,7 @@ package mlflow
 // bazel test --test_output=all //:dbfs_artifact_repo_test
 
 import (
-	"bytes"
-	"errors"
+	"context"
 	"fmt"
 	"io"
 	"io/fs"
@@ -16,16 +15,31 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/Astera-org/mlflow-go/protos"
 )
 
-const (
-	dbfsMaxUploadFileSize = 64 * 1024 * 1024
+// Files of at least dbfsMultipartUploadMinSize bytes are uploaded in parts of dbfsUploadPartSize bytes,
+// since cloud storage limits the size of a single upload. Variables so that tests can use small files.
+var (
+	dbfsMultipartUploadMinSize int64 = 64 * 1024 * 1024
+	dbfsUploadPartSize         int64 = 64 * 1024 * 1024
+	// How many files LogArtifacts requests write credentials for at once.
+	dbfsCredentialsBatchSize = 50
 )
 
+// How long to wait for a failed multipart upload to be aborted.
+const dbfsAbortTimeout = time.Minute
+
 // DBFSArtifactRepo uploads to DBFS (Databricks File System).
 // Generally it is used indirectly via [Run.LogArtifact].
+//
+// Large files are uploaded in parts, which are streamed from disk and retried individually.
+// If any part can't be uploaded, the whole upload is aborted.
 type DBFSArtifactRepo struct {
 	// Based on
 	// https://github.com/mlflow/mlflow/blob/e7ff52d724e3218704fde225493e52c5acd41bb6/mlflow/store/artifact/databricks_artifact_repo.py
@@ -47,87 +61,405 @@ func NewDBFSArtifactRepo(restStore *RESTStore, uri string) (ArtifactRepo, error)
 	return &DBFSArtifactRepo{restStore, parsed.Path, path.Base(path.Dir(parsed.Path))}, nil
 }
 
+// Implements [ArtifactRepo.WithContext].
+func (repo *DBFSArtifactRepo) WithContext(ctx context.Context) ArtifactRepo {
+	c := *repo
+	c.rest = repo.rest.WithContext(ctx).(*RESTStore)
+	return &c
+}
+
 // Implements [ArtifactRepo.LogArtifact].
+// Like [FileArtifactRepo], if localPath is a directory, its tree is logged under artifactPath/<base name of localPath>.
 func (repo *DBFSArtifactRepo) LogArtifact(localPath, artifactPath string) error {
-	destPath := path.Join(artifactPath, path.Base(localPath))
-	getCredsReq := protos.GetCredentialsForWrite{
-		RunId: &repo.runID,
-		Path:  []string{destPath},
+	destPath := path.Join(artifactPath, filepath.Base(localPath))
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		return repo.LogArtifacts(localPath, destPath)
 	}
-	var getCredsRes protos.GetCredentialsForWrite_Response
-	if err := repo.rest.do(http.MethodPost, "artifacts/credentials-for-write", &getCredsReq, &getCredsRes); err != nil {
-		return err
-	}
-	if len(getCredsRes.CredentialInfos) != 1 {
-		return fmt.Errorf("expected 1 credential, got %d", len(getCredsRes.CredentialInfos))
-	}
-	credInfo := getCredsRes.CredentialInfos[0]
+	return repo.uploadFile(localPath, destPath, nil)
+}
 
+// uploadFile uploads the file at localPath to destPath. credInfo is the credential for destPath,
+// or nil to request one. It is not used if the file is uploaded in parts.
+func (repo *DBFSArtifactRepo) uploadFile(localPath, destPath string, credInfo *protos.ArtifactCredentialInfo) error {
 	f, err := os.Open(localPath)
 	if err != nil {
 		return fmt.Errorf("failed to open file %q, %v", localPath, err)
 	}
-	// We have to read the file into memory here rather than pass the file
-	// in to http.NewRequest. Otherwise it will set the Transfer-Encoding
-	// header to chunked, which AWS S3 does not support.
-	localBytes := make([]byte, dbfsMaxUploadFileSize)
-	n, err := f.Read(localBytes)
-	if n == dbfsMaxUploadFileSize {
-		return fmt.Errorf("file %q is too large (>= %d bytes) to upload in a single shot. "+
-			"There must be some way to handle this but we don't yet", localPath, dbfsMaxUploadFileSize)
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file %q, %w", localPath, err)
+	}
+	if stat.Size() >= dbfsMultipartUploadMinSize {
+		return repo.logArtifactInParts(f, stat.Size(), destPath)
+	}
+
+	if credInfo == nil {
+		credInfos, err := repo.credentialsForWrite([]string{destPath})
+		if err != nil {
+			return err
+		}
+		credInfo = credInfos[destPath]
 	}
-	f.Close()
+	httpRes, resBody, err := repo.put(credInfo, f, 0, stat.Size())
 	if err != nil {
-		if errors.Is(err, io.EOF) {
-			err = nil
-		} else {
-			return fmt.Errorf("failed to read file %q, %w", localPath, err)
+		return fmt.Errorf("failed to upload artifact using signed URI %s: %w", credInfo.GetSignedUri(), err)
+	}
+	if httpRes.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to upload artifact using signed URI %s with status %s: %s",
+			credInfo.GetSignedUri(), httpRes.Status, resBody)
+	}
+	return nil
+}
+
+// credentialsForWrite requests credentials for all of paths in one request, and returns them by path.
+// Based on
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/store/artifact/databricks_artifact_repo.py#L206
+func (repo *DBFSArtifactRepo) credentialsForWrite(paths []string) (map[string]*protos.ArtifactCredentialInfo, error) {
+	credInfos := make(map[string]*protos.ArtifactCredentialInfo, len(paths))
+	var pageToken *string
+	for {
+		req := protos.GetCredentialsForWrite{RunId: &repo.runID, Path: paths, PageToken: pageToken}
+		var res protos.GetCredentialsForWrite_Response
+		if err := repo.rest.do(http.MethodPost, "artifacts/credentials-for-write", &req, &res); err != nil {
+			return nil, err
+		}
+		for _, credInfo := range res.CredentialInfos {
+			credInfos[credInfo.GetPath()] = credInfo
+		}
+		if res.GetNextPageToken() == "" || len(res.CredentialInfos) == 0 {
+			break
+		}
+		pageToken = res.NextPageToken
+	}
+	for _, p := range paths {
+		if credInfos[p] == nil {
+			return nil, fmt.Errorf("no credential for artifact %s in response", p)
+		}
+	}
+	return credInfos, nil
+}
+
+// put uploads size bytes of f starting at offset to the signed URI in credInfo.
+// Uploads use the same retry policy as the tracking server requests.
+func (repo *DBFSArtifactRepo) put(credInfo *protos.ArtifactCredentialInfo, f *os.File, offset, size int64) (*http.Response, []byte, error) {
+	return repo.rest.send(repo.rest.client, func() (*http.Request, error) {
+		// The body is streamed from the file, but with the length set so that the
+		// Transfer-Encoding isn't chunked, which AWS S3 does not support.
+		var body io.Reader = http.NoBody
+		if size > 0 {
+			body = io.NewSectionReader(f, offset, size)
+		}
+		httpReq, err := http.NewRequestWithContext(repo.rest.ctx, http.MethodPut, credInfo.GetSignedUri(), body)
+		if err != nil {
+			return nil, err
+		}
+		httpReq.ContentLength = size
+		for _, header := range credInfo.Headers {
+			httpReq.Header.Add(header.GetName(), header.GetValue())
+		}
+		return httpReq, nil
+	})
+}
+
+// logArtifactInParts uploads f, which is size bytes, to destPath with a multipart upload.
+// Based on
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/store/artifact/databricks_artifact_repo.py#L469
+func (repo *DBFSArtifactRepo) logArtifactInParts(f *os.File, size int64, destPath string) error {
+	numParts := (size + dbfsUploadPartSize - 1) / dbfsUploadPartSize
+	var createRes protos.CreateMultipartUpload_Response
+	if err := repo.rest.do(http.MethodPost,
+		"artifacts/create-multipart-upload",
+		protos.CreateMultipartUpload{RunId: &repo.runID, Path: &destPath, NumParts: &numParts},
+		&createRes); err != nil {
+		return err
+	}
+	err := repo.uploadParts(f, size, destPath, &createRes)
+	if err != nil && createRes.AbortCredentialInfo != nil {
+		if abortErr := repo.abortMultipartUpload(createRes.AbortCredentialInfo); abortErr != nil {
+			return fmt.Errorf("%w; also failed to abort the upload: %v", err, abortErr)
+		}
+	}
+	return err
+}
+
+// uploadParts uploads each part of f and then completes the multipart upload.
+func (repo *DBFSArtifactRepo) uploadParts(f *os.File, size int64, destPath string, createRes *protos.CreateMultipartUpload_Response) error {
+	numParts := (size + dbfsUploadPartSize - 1) / dbfsUploadPartSize
+	if int64(len(createRes.UploadCredentialInfos)) != numParts {
+		return fmt.Errorf("expected %d upload credentials, got %d", numParts, len(createRes.UploadCredentialInfos))
+	}
+	partEtags := make([]*protos.PartEtag, numParts)
+	for i, credInfo := range createRes.UploadCredentialInfos {
+		partNumber := int64(i + 1)
+		offset := int64(i) * dbfsUploadPartSize
+		partSize := dbfsUploadPartSize
+		if offset+partSize > size {
+			partSize = size - offset
+		}
+		etag, err := repo.uploadPart(f, offset, partSize, partNumber, destPath, createRes.GetUploadId(), credInfo)
+		if err != nil {
+			return err
+		}
+		partEtags[i] = &protos.PartEtag{PartNumber: &partNumber, Etag: &etag}
+	}
+	var completeRes protos.CompleteMultipartUpload_Response
+	return repo.rest.do(http.MethodPost,
+		"artifacts/complete-multipart-upload",
+		protos.CompleteMultipartUpload{RunId: &repo.runID, Path: &destPath, UploadId: createRes.UploadId, PartEtags: partEtags},
+		&completeRes)
+}
+
+// uploadPart uploads one part of a multipart upload and returns its ETag.
+func (repo *DBFSArtifactRepo) uploadPart(f *os.File, offset, size, partNumber int64, destPath, uploadID string, credInfo *protos.ArtifactCredentialInfo) (string, error) {
+	httpRes, resBody, err := repo.put(credInfo, f, offset, size)
+	if err == nil && httpRes.StatusCode == http.StatusForbidden {
+		// The presigned URL may have expired while earlier parts were uploaded, so get a new one.
+		query := url.Values{}
+		query.Set("run_id", repo.runID)
+		query.Set("path", destPath)
+		query.Set("upload_id", uploadID)
+		query.Set("part_number", strconv.FormatInt(partNumber, 10))
+		var urlRes protos.GetPresignedUploadPartUrl_Response
+		if err := repo.rest.do(http.MethodGet, "artifacts/get-presigned-upload-part-url?"+query.Encode(), nil, &urlRes); err != nil {
+			return "", err
 		}
+		credInfo = urlRes.UploadCredentialInfo
+		httpRes, resBody, err = repo.put(credInfo, f, offset, size)
 	}
-	localBytes = localBytes[:n]
-	httpReq, err := http.NewRequest(http.MethodPut, *credInfo.SignedUri, bytes.NewReader(localBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part %d of artifact %s: %w", partNumber, destPath, err)
+	}
+	if httpRes.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to upload part %d of artifact %s with status %s: %s",
+			partNumber, destPath, httpRes.Status, resBody)
+	}
+	etag := httpRes.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("no ETag in response to upload of part %d of artifact %s", partNumber, destPath)
+	}
+	return etag, nil
+}
+
+// abortMultipartUpload deletes the parts uploaded so far, so that they aren't stored forever.
+func (repo *DBFSArtifactRepo) abortMultipartUpload(credInfo *protos.ArtifactCredentialInfo) error {
+	// Not repo's context, since the upload may have failed because it was cancelled.
+	ctx, cancel := context.WithTimeout(context.Background(), dbfsAbortTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, credInfo.GetSignedUri(), nil)
 	if err != nil {
 		return err
 	}
 	for _, header := range credInfo.Headers {
-		httpReq.Header.Add(*header.Name, *header.Value)
+		httpReq.Header.Add(header.GetName(), header.GetValue())
 	}
-	httpRes, err := http.DefaultClient.Do(httpReq)
+	httpRes, err := repo.rest.client.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("failed to upload artifact using signed URI %s: %v", *credInfo.SignedUri, err)
+		return err
 	}
 	defer httpRes.Body.Close()
-	resBody, err := io.ReadAll(httpRes.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %v", err)
-	}
-	if httpRes.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to upload artifact using signed URI %s with status %s: %s",
-			*credInfo.SignedUri, httpRes.Status, resBody)
+	if httpRes.StatusCode < 200 || httpRes.StatusCode >= 300 {
+		resBody, _ := io.ReadAll(httpRes.Body)
+		return fmt.Errorf("status %s: %s", httpRes.Status, resBody)
 	}
 	return nil
 }
 
+// dbfsUpload is a file to upload with LogArtifacts.
+type dbfsUpload struct {
+	localPath string
+	destPath  string
+	size      int64
+}
+
 // Implements [ArtifactRepo.LogArtifacts].
+//
+// Files are uploaded concurrently, see [WithArtifactUploadWorkers], with the credentials for a batch
+// of files requested at once. If some files fail to upload the rest are still uploaded,
+// and the error is an [*ArtifactUploadError].
 func (repo *DBFSArtifactRepo) LogArtifacts(localPath, artifactPath string) error {
-	// We want to keep only the last directory in the path.
-	// This is because the python client does this.
-	prefixToStrip := filepath.Dir(localPath) + "/"
-	if prefixToStrip == "./" {
-		prefixToStrip = ""
-	}
-	return filepath.WalkDir(localPath, func(curPath string, curEntry fs.DirEntry, err error) error {
+	var uploads []dbfsUpload
+	err := filepath.WalkDir(localPath, func(curPath string, curEntry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := repo.rest.ctx.Err(); err != nil {
+			return err
+		}
 		if curEntry.IsDir() {
 			return nil
 		}
-		// Make relative.
-		destDir := filepath.Dir(curPath)[len(prefixToStrip):]
-		if artifactPath != "" {
-			destDir = path.Join(artifactPath, destDir)
+		info, err := curEntry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, curPath)
+		if err != nil {
+			return err
 		}
-		return repo.LogArtifact(curPath, destDir)
+		uploads = append(uploads, dbfsUpload{curPath, path.Join(artifactPath, filepath.ToSlash(rel)), info.Size()})
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+	return repo.uploadFiles(uploads)
+}
+
+// uploadFiles uploads files with a pool of workers. Credentials are requested in batches just before
+// the files are uploaded, so that they don't expire while waiting for earlier files.
+func (repo *DBFSArtifactRepo) uploadFiles(uploads []dbfsUpload) error {
+	type job struct {
+		upload   dbfsUpload
+		credInfo *protos.ArtifactCredentialInfo
+	}
+	jobs := make(chan job)
+	var (
+		mtx  sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	addError := func(err error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		errs = append(errs, err)
+	}
+	numWorkers := repo.rest.uploadWorkers
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	for i := 0; i < numWorkers && i < len(uploads); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if err := repo.uploadFile(j.upload.localPath, j.upload.destPath, j.credInfo); err != nil {
+					addError(fmt.Errorf("error uploading %s: %w", j.upload.localPath, err))
+				}
+			}
+		}()
+	}
+
+	ctx := repo.rest.ctx
+	sendJobs := func() {
+		for start := 0; start < len(uploads); start += dbfsCredentialsBatchSize {
+			end := start + dbfsCredentialsBatchSize
+			if end > len(uploads) {
+				end = len(uploads)
+			}
+			batch := uploads[start:end]
+			var paths []string
+			for _, u := range batch {
+				if u.size < dbfsMultipartUploadMinSize {
+					paths = append(paths, u.destPath)
+				}
+			}
+			var credInfos map[string]*protos.ArtifactCredentialInfo
+			var credsErr error
+			if len(paths) > 0 {
+				credInfos, credsErr = repo.credentialsForWrite(paths)
+			}
+			for _, u := range batch {
+				// Files that are uploaded in parts don't need the credentials.
+				if credsErr != nil && u.size < dbfsMultipartUploadMinSize {
+					addError(fmt.Errorf("error getting credentials to upload %s: %w", u.localPath, credsErr))
+					continue
+				}
+				select {
+				case jobs <- job{u, credInfos[u.destPath]}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+	sendJobs()
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return &ArtifactUploadError{Errors: errs}
+	}
+	return nil
+}
+
+// Implements [ArtifactRepo.ListArtifacts].
+// Based on
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/store/artifact/databricks_artifact_repo.py#L606
+func (repo *DBFSArtifactRepo) ListArtifacts(artifactPath string) ([]ArtifactInfo, error) {
+	dirPath := cleanArtifactPath(artifactPath)
+	infos := []ArtifactInfo{}
+	pageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("run_id", repo.runID)
+		query.Set("path", dirPath)
+		if pageToken != "" {
+			query.Set("page_token", pageToken)
+		}
+		var res protos.ListArtifacts_Response
+		if err := repo.rest.do(http.MethodGet, "artifacts/list?"+query.Encode(), nil, &res); err != nil {
+			return nil, err
+		}
+		// Listing a file returns just that file, but it should return nothing.
+		if len(res.Files) == 1 && res.Files[0].GetPath() == dirPath && !res.Files[0].GetIsDir() {
+			return []ArtifactInfo{}, nil
+		}
+		for _, file := range res.Files {
+			infos = append(infos, ArtifactInfo{Path: file.GetPath(), IsDir: file.GetIsDir(), Size: file.GetFileSize()})
+		}
+		if len(res.Files) == 0 || res.GetNextPageToken() == "" {
+			break
+		}
+		pageToken = res.GetNextPageToken()
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	return infos, nil
+}
+
+// Implements [ArtifactRepo.DownloadArtifacts].
+func (repo *DBFSArtifactRepo) DownloadArtifacts(artifactPath, dstDir string) (string, error) {
+	return DownloadArtifacts(repo, artifactPath, dstDir)
+}
+
+// Implements [ArtifactRepo.OpenArtifact].
+// The download is retried if it fails to start, but not if it fails part way through.
+func (repo *DBFSArtifactRepo) OpenArtifact(artifactPath string) (io.ReadCloser, error) {
+	artifactPath = cleanArtifactPath(artifactPath)
+	var credsRes protos.GetCredentialsForRead_Response
+	if err := repo.rest.do(http.MethodPost,
+		"artifacts/credentials-for-read",
+		protos.GetCredentialsForRead{RunId: &repo.runID, Path: []string{artifactPath}},
+		&credsRes); err != nil {
+		return nil, err
+	}
+	if len(credsRes.CredentialInfos) != 1 {
+		return nil, fmt.Errorf("expected 1 credential, got %d", len(credsRes.CredentialInfos))
+	}
+	credInfo := credsRes.CredentialInfos[0]
+	httpRes, resBody, err := repo.rest.sendForBody(repo.rest.client, func() (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(repo.rest.ctx, http.MethodGet, credInfo.GetSignedUri(), nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, header := range credInfo.Headers {
+			httpReq.Header.Add(header.GetName(), header.GetValue())
+		}
+		return httpReq, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artifact using signed URI %s: %w", credInfo.GetSignedUri(), err)
+	}
+	switch httpRes.StatusCode {
+	case http.StatusOK:
+		return httpRes.Body, nil
+	case http.StatusNotFound:
+		return nil, notFoundArtifact(artifactPath)
+	}
+	return nil, fmt.Errorf("failed to download artifact using signed URI %s with status %s: %s",
+		credInfo.GetSignedUri(), httpRes.Status, resBody)
 }
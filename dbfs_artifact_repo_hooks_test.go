@@ -0,0 +1,23 @@
+package mlflow
+
+import "testing"
+
+// SetDBFSUploadPartSize makes DBFSArtifactRepo upload files of at least size bytes in parts of that
+// size, until the end of the test. It is for tests in package mlflow_test, which can't set unexported variables.
+func SetDBFSUploadPartSize(t testing.TB, size int64) {
+	oldMinSize, oldPartSize := dbfsMultipartUploadMinSize, dbfsUploadPartSize
+	dbfsMultipartUploadMinSize, dbfsUploadPartSize = size, size
+	t.Cleanup(func() {
+		dbfsMultipartUploadMinSize, dbfsUploadPartSize = oldMinSize, oldPartSize
+	})
+}
+
+// SetDBFSCredentialsBatchSize makes DBFSArtifactRepo.LogArtifacts request write credentials for at most n files
+// at once, until the end of the test.
+func SetDBFSCredentialsBatchSize(t testing.TB, n int) {
+	old := dbfsCredentialsBatchSize
+	dbfsCredentialsBatchSize = n
+	t.Cleanup(func() {
+		dbfsCredentialsBatchSize = old
+	})
+}
@@ -0,0 +1,124 @@
+package mlflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+// Sentinel errors that can be checked for with errors.Is.
+// All Tracking implementations return errors that match these where applicable.
+var (
+	ErrNotFound         = errors.New("resource does not exist")
+	ErrAlreadyExists    = errors.New("resource already exists")
+	ErrInvalidParameter = errors.New("invalid parameter value")
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// Error is an error reported by MLFlow. Use errors.As to get the details,
+// or errors.Is with one of the sentinel errors, e.g. [ErrNotFound], to check the kind of error.
+type Error struct {
+	// One of the ErrorCode names in protos/databricks.proto, e.g. "RESOURCE_DOES_NOT_EXIST".
+	Code    string
+	Message string
+	// The HTTP status code of the response, or 0 if the error did not come from a tracking server.
+	StatusCode int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Is implements matching with errors.Is.
+func (e *Error) Is(target error) bool {
+	var codes []protos.ErrorCode
+	switch target {
+	case ErrNotFound:
+		codes = []protos.ErrorCode{protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, protos.ErrorCode_NOT_FOUND}
+	case ErrAlreadyExists:
+		codes = []protos.ErrorCode{protos.ErrorCode_RESOURCE_ALREADY_EXISTS, protos.ErrorCode_ALREADY_EXISTS}
+	case ErrInvalidParameter:
+		codes = []protos.ErrorCode{
+			protos.ErrorCode_INVALID_PARAMETER_VALUE, protos.ErrorCode_BAD_REQUEST, protos.ErrorCode_MALFORMED_REQUEST}
+	case ErrPermissionDenied:
+		codes = []protos.ErrorCode{
+			protos.ErrorCode_PERMISSION_DENIED, protos.ErrorCode_UNAUTHENTICATED, protos.ErrorCode_CUSTOMER_UNAUTHORIZED}
+	}
+	for _, code := range codes {
+		if e.Code == code.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func newError(code protos.ErrorCode, format string, args ...interface{}) *Error {
+	return &Error{Code: code.String(), Message: fmt.Sprintf(format, args...)}
+}
+
+// statusErrorCodes is used for error responses that don't include an error code.
+var statusErrorCodes = map[int]protos.ErrorCode{
+	http.StatusBadRequest:          protos.ErrorCode_BAD_REQUEST,
+	http.StatusUnauthorized:        protos.ErrorCode_UNAUTHENTICATED,
+	http.StatusForbidden:           protos.ErrorCode_PERMISSION_DENIED,
+	http.StatusNotFound:            protos.ErrorCode_ENDPOINT_NOT_FOUND,
+	http.StatusTooManyRequests:     protos.ErrorCode_REQUEST_LIMIT_EXCEEDED,
+	http.StatusInternalServerError: protos.ErrorCode_INTERNAL_ERROR,
+	http.StatusServiceUnavailable:  protos.ErrorCode_TEMPORARILY_UNAVAILABLE,
+}
+
+// errorFromResponse parses an MLFlow error response body, which looks like
+// {"error_code": "RESOURCE_DOES_NOT_EXIST", "message": "..."}.
+func errorFromResponse(statusCode int, body []byte) *Error {
+	var parsed struct {
+		ErrorCode string `json:"error_code"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil || parsed.ErrorCode == "" {
+		code, ok := statusErrorCodes[statusCode]
+		if !ok {
+			code = protos.ErrorCode_UNPARSEABLE_HTTP_ERROR
+		}
+		return &Error{Code: code.String(), Message: strings.TrimSpace(string(body)), StatusCode: statusCode}
+	}
+	return &Error{Code: parsed.ErrorCode, Message: parsed.Message, StatusCode: statusCode}
+}
+
+// ArtifactUploadError is returned when some of the files in a directory could not be uploaded.
+// The other files are still uploaded. errors.Is and errors.As match any of the underlying errors.
+type ArtifactUploadError struct {
+	// One error per failed file, sorted by message.
+	Errors []error
+}
+
+func (e *ArtifactUploadError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "failed to upload artifacts: " + strings.Join(msgs, "; ")
+}
+
+// Is implements matching with errors.Is.
+func (e *ArtifactUploadError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As implements matching with errors.As.
+func (e *ArtifactUploadError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,516 @@
+package mlflow
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+// The layout of an archive written by [Export]:
+//
+//	experiments.json            all exported experiments
+//	runs/<run ID>/run.json      metadata, params, tags and metric histories of a run
+//	runs/<run ID>/artifacts/... the run's artifacts
+const (
+	archiveFormatVersion       = 1
+	archiveExperimentsFileName = "experiments.json"
+	archiveRunsDirName         = "runs"
+	archiveRunFileName         = "run.json"
+)
+
+type archiveExperiments struct {
+	FormatVersion int                 `json:"format_version"`
+	Experiments   []archiveExperiment `json:"experiments"`
+}
+
+type archiveExperiment struct {
+	ID   string       `json:"experiment_id"`
+	Name string       `json:"name"`
+	Tags []archiveTag `json:"tags,omitempty"`
+}
+
+type archiveTag struct {
+	Key string `json:"key"`
+	Val string `json:"value"`
+}
+
+type archiveMetric struct {
+	Key       string      `json:"key"`
+	Val       jsonFloat64 `json:"value"`
+	Step      int64       `json:"step"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// jsonFloat64 is a float64 that can also be NaN or infinite in JSON. Like in MLflow, those values are
+// encoded as the strings "NaN", "Infinity" and "-Infinity", since JSON numbers can't represent them.
+type jsonFloat64 float64
+
+func (f jsonFloat64) MarshalJSON() ([]byte, error) {
+	switch v := float64(f); {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Infinity"`), nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+func (f *jsonFloat64) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var v float64
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*f = jsonFloat64(v)
+		return nil
+	}
+	switch s {
+	case "NaN":
+		*f = jsonFloat64(math.NaN())
+	case "Infinity":
+		*f = jsonFloat64(math.Inf(1))
+	case "-Infinity":
+		*f = jsonFloat64(math.Inf(-1))
+	default:
+		return fmt.Errorf("invalid number %q", s)
+	}
+	return nil
+}
+
+type archiveRun struct {
+	ID           string `json:"run_id"`
+	ExperimentID string `json:"experiment_id"`
+	Name         string `json:"run_name"`
+	// The name of the status, e.g. "FINISHED".
+	Status string `json:"status"`
+	// Milliseconds since the Unix epoch. EndTime is 0 if the run has not ended.
+	StartTime int64           `json:"start_time"`
+	EndTime   int64           `json:"end_time,omitempty"`
+	Params    []archiveTag    `json:"params,omitempty"`
+	Tags      []archiveTag    `json:"tags,omitempty"`
+	Metrics   []archiveMetric `json:"metrics,omitempty"`
+
+	// The directory the run was extracted to by Import.
+	dir string
+}
+
+// ExportOptions configures [Export].
+type ExportOptions struct {
+	// If set, only these experiments are exported. Defaults to all active experiments.
+	ExperimentIDs []string
+	// If set, only these runs are exported, along with their experiments, even if they are deleted.
+	// They must be in the experiments that are being exported. Experiments without any of these runs
+	// are left out.
+	RunIDs []string
+}
+
+// ExportResult reports what [Export] wrote.
+type ExportResult struct {
+	ExperimentIDs []string
+	RunIDs        []string
+}
+
+// Export writes experiments and their active runs from src to w as a gzipped tar archive,
+// which can be read with [Import]. The archive includes each run's params, tags, metric histories,
+// status, start and end times, and artifacts. Artifacts are read with [Run.OpenArtifact],
+// so they can be in any supported location, e.g. DBFS.
+func Export(src Tracking, w io.Writer, opts ExportOptions) (ExportResult, error) {
+	var result ExportResult
+	experimentIDs := opts.ExperimentIDs
+	if len(experimentIDs) == 0 {
+		exps, err := src.ExperimentsByName()
+		if err != nil {
+			return result, err
+		}
+		for _, exp := range exps {
+			experimentIDs = append(experimentIDs, exp.ID())
+		}
+	}
+	wantedRuns := stringSet(opts.RunIDs)
+	// Runs that are asked for by ID are exported even if they are deleted.
+	viewType := ViewTypeActiveOnly
+	if len(opts.RunIDs) > 0 {
+		viewType = ViewTypeAll
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest := archiveExperiments{FormatVersion: archiveFormatVersion}
+	for _, id := range experimentIDs {
+		exp, err := src.GetExperiment(id)
+		if err != nil {
+			return result, fmt.Errorf("mlflow.Export: error getting experiment %s: %w", id, err)
+		}
+		info, err := exp.Info()
+		if err != nil {
+			return result, fmt.Errorf("mlflow.Export: error getting experiment %s: %w", id, err)
+		}
+
+		exportedRuns := 0
+		pageToken := ""
+		for {
+			runs, nextPageToken, err := src.SearchRuns([]string{id}, "", nil, pageToken, WithViewType(viewType))
+			if err != nil {
+				return result, fmt.Errorf("mlflow.Export: error searching runs: %w", err)
+			}
+			for _, run := range runs {
+				if len(opts.RunIDs) > 0 && !wantedRuns[run.ID()] {
+					continue
+				}
+				delete(wantedRuns, run.ID())
+				if err := exportRun(tw, run); err != nil {
+					return result, fmt.Errorf("mlflow.Export: error exporting run %s: %w", run.ID(), err)
+				}
+				result.RunIDs = append(result.RunIDs, run.ID())
+				exportedRuns++
+			}
+			if nextPageToken == "" {
+				break
+			}
+			pageToken = nextPageToken
+		}
+		// Only the experiments of the selected runs are exported.
+		if len(opts.RunIDs) > 0 && exportedRuns == 0 {
+			continue
+		}
+		archived := archiveExperiment{ID: info.ID, Name: info.Name}
+		for _, tag := range info.Tags {
+			archived.Tags = append(archived.Tags, archiveTag{Key: tag.Key, Val: tag.Val})
+		}
+		manifest.Experiments = append(manifest.Experiments, archived)
+		result.ExperimentIDs = append(result.ExperimentIDs, info.ID)
+	}
+	if len(wantedRuns) > 0 {
+		missing := make([]string, 0, len(wantedRuns))
+		for id := range wantedRuns {
+			missing = append(missing, id)
+		}
+		sort.Strings(missing)
+		return result, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "runs %s are not in the exported experiments", strings.Join(missing, ", "))
+	}
+	if err := writeArchiveJSON(tw, archiveExperimentsFileName, manifest); err != nil {
+		return result, err
+	}
+	if err := tw.Close(); err != nil {
+		return result, fmt.Errorf("mlflow.Export: error writing archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return result, fmt.Errorf("mlflow.Export: error writing archive: %w", err)
+	}
+	return result, nil
+}
+
+// exportRun writes run and its artifacts to tw.
+func exportRun(tw *tar.Writer, run Run) error {
+	info, err := run.Info()
+	if err != nil {
+		return err
+	}
+	archived := archiveRun{
+		ID:           info.ID,
+		ExperimentID: info.ExperimentID,
+		Name:         info.Name,
+		Status:       protos.RunStatus(info.Status).String(),
+		StartTime:    info.StartTime.UnixMilli(),
+	}
+	if !info.EndTime.IsZero() {
+		archived.EndTime = info.EndTime.UnixMilli()
+	}
+	params, err := run.Params()
+	if err != nil {
+		return err
+	}
+	for _, param := range params {
+		archived.Params = append(archived.Params, archiveTag{Key: param.Key, Val: param.Val})
+	}
+	tags, err := run.Tags()
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		archived.Tags = append(archived.Tags, archiveTag{Key: tag.Key, Val: tag.Val})
+	}
+	latest, err := run.GetLatestMetrics()
+	if err != nil {
+		return err
+	}
+	for _, m := range latest {
+		history, err := fullMetricHistory(run, m.Key)
+		if err != nil {
+			return err
+		}
+		for _, h := range history {
+			archived.Metrics = append(archived.Metrics,
+				archiveMetric{Key: h.Key, Val: jsonFloat64(h.Val), Step: h.Step, Timestamp: h.Timestamp.UnixMilli()})
+		}
+	}
+	runDir := path.Join(archiveRunsDirName, info.ID)
+	if err := writeArchiveJSON(tw, path.Join(runDir, archiveRunFileName), archived); err != nil {
+		return err
+	}
+	return writeArchiveArtifacts(tw, run, "", path.Join(runDir, artifactsFolderName))
+}
+
+func writeArchiveJSON(tw *tar.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("mlflow.Export: error writing archive: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("mlflow.Export: error writing archive: %w", err)
+	}
+	return nil
+}
+
+// writeArchiveArtifacts writes the artifacts of run in the directory at artifactPath, and the trees
+// under them, to tw under prefix.
+func writeArchiveArtifacts(tw *tar.Writer, run Run, artifactPath, prefix string) error {
+	entries, err := run.ListArtifacts(artifactPath)
+	if err != nil {
+		return fmt.Errorf("error listing artifacts: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir {
+			err = writeArchiveArtifacts(tw, run, entry.Path, prefix)
+		} else {
+			err = writeArchiveArtifact(tw, run, entry, prefix)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeArchiveArtifact(tw *tar.Writer, run Run, entry ArtifactInfo, prefix string) error {
+	r, err := run.OpenArtifact(entry.Path)
+	if err != nil {
+		return fmt.Errorf("error reading artifact %s: %w", entry.Path, err)
+	}
+	defer r.Close()
+	header := &tar.Header{Name: path.Join(prefix, entry.Path), Mode: 0644, Size: entry.Size, ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("mlflow.Export: error writing archive: %w", err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("mlflow.Export: error writing artifact %s: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// ImportResult reports what [Import] created.
+type ImportResult struct {
+	// Maps the IDs of experiments in the archive to the IDs of the experiments they were imported into.
+	ExperimentIDs map[string]string
+	// Maps the IDs of runs in the archive to the IDs of the runs created for them.
+	RunIDs map[string]string
+}
+
+// Import creates the experiments and runs in an archive written by [Export] in dst.
+// Experiments are matched by name, and are created if needed. Runs are always created,
+// with new IDs and the [SourceRunIDTagKey] tag set to their ID in the archive.
+// [ParentRunIDTagKey] tags are updated to the new IDs of parent runs in the archive.
+func Import(dst Tracking, r io.Reader) (ImportResult, error) {
+	result := ImportResult{ExperimentIDs: make(map[string]string), RunIDs: make(map[string]string)}
+	// Artifacts have to be on disk to be logged, so extract everything first.
+	dir, err := os.MkdirTemp("", "mlflow-import")
+	if err != nil {
+		return result, fmt.Errorf("mlflow.Import: error creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := extractArchive(r, dir); err != nil {
+		return result, fmt.Errorf("mlflow.Import: error extracting archive: %w", err)
+	}
+
+	var manifest archiveExperiments
+	if err := readArchiveJSON(filepath.Join(dir, archiveExperimentsFileName), &manifest); err != nil {
+		return result, fmt.Errorf("mlflow.Import: %w", err)
+	}
+	if manifest.FormatVersion != archiveFormatVersion {
+		return result, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "unsupported archive format version %d", manifest.FormatVersion)
+	}
+	experiments := make(map[string]Experiment, len(manifest.Experiments))
+	for _, archived := range manifest.Experiments {
+		exp, err := dst.GetOrCreateExperimentWithName(archived.Name)
+		if err != nil {
+			return result, fmt.Errorf("mlflow.Import: error creating experiment %s: %w", archived.Name, err)
+		}
+		for _, tag := range archived.Tags {
+			if err := exp.SetTag(tag.Key, tag.Val); err != nil {
+				return result, fmt.Errorf("mlflow.Import: error setting experiment tag: %w", err)
+			}
+		}
+		experiments[archived.ID] = exp
+		result.ExperimentIDs[archived.ID] = exp.ID()
+	}
+
+	runsDir := filepath.Join(dir, archiveRunsDirName)
+	entries, err := os.ReadDir(runsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return result, fmt.Errorf("mlflow.Import: error reading archive: %w", err)
+	}
+	runs := make(map[string]*archiveRun, len(entries))
+	ordered := make([]*archiveRun, 0, len(entries))
+	for _, entry := range entries {
+		run := &archiveRun{dir: filepath.Join(runsDir, entry.Name())}
+		if err := readArchiveJSON(filepath.Join(run.dir, archiveRunFileName), run); err != nil {
+			return result, fmt.Errorf("mlflow.Import: %w", err)
+		}
+		runs[run.ID] = run
+		ordered = append(ordered, run)
+	}
+	// Parents are imported before their children, so that the children can refer to their new IDs.
+	var importRun func(run *archiveRun) error
+	importRun = func(run *archiveRun) error {
+		if _, ok := result.RunIDs[run.ID]; ok {
+			return nil
+		}
+		// Mark as in progress to stop cycles.
+		result.RunIDs[run.ID] = ""
+		if parent, ok := runs[archiveTagValue(run.Tags, ParentRunIDTagKey)]; ok {
+			if err := importRun(parent); err != nil {
+				return err
+			}
+		}
+		exp, ok := experiments[run.ExperimentID]
+		if !ok {
+			return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "run %s is in experiment %s, which is not in the archive", run.ID, run.ExperimentID)
+		}
+		newID, err := importArchiveRun(exp, run, result.RunIDs)
+		if err != nil {
+			return fmt.Errorf("mlflow.Import: error importing run %s: %w", run.ID, err)
+		}
+		result.RunIDs[run.ID] = newID
+		return nil
+	}
+	for _, run := range ordered {
+		if err := importRun(run); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// importArchiveRun creates run in exp and returns its ID.
+// newRunIDs maps the IDs of runs in the archive to the IDs of runs already imported.
+func importArchiveRun(exp Experiment, run *archiveRun, newRunIDs map[string]string) (string, error) {
+	status, ok := protos.RunStatus_value[run.Status]
+	if !ok {
+		return "", newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "unknown run status %q", run.Status)
+	}
+	tags := make([]Tag, 0, len(run.Tags)+1)
+	for _, tag := range run.Tags {
+		if tag.Key == ParentRunIDTagKey && newRunIDs[tag.Val] != "" {
+			tag.Val = newRunIDs[tag.Val]
+		}
+		tags = append(tags, Tag{Key: tag.Key, Val: tag.Val})
+	}
+	tags = append(tags, Tag{Key: SourceRunIDTagKey, Val: run.ID})
+	created, err := exp.CreateRun(run.Name, WithStartTime(time.UnixMilli(run.StartTime)), WithTags(tags))
+	if err != nil {
+		return "", err
+	}
+	params := make([]Param, len(run.Params))
+	for i, param := range run.Params {
+		params[i] = Param{Key: param.Key, Val: param.Val}
+	}
+	metrics := make([]LoggedMetric, len(run.Metrics))
+	for i, m := range run.Metrics {
+		metrics[i] = LoggedMetric{Key: m.Key, Val: float64(m.Val), Step: m.Step, Timestamp: time.UnixMilli(m.Timestamp)}
+	}
+	if len(params)+len(metrics) > 0 {
+		if err := created.LogBatch(metrics, params, nil); err != nil {
+			return "", err
+		}
+	}
+	if err := logArtifactDir(filepath.Join(run.dir, artifactsFolderName), created); err != nil {
+		return "", err
+	}
+	if RunStatus(status).IsTerminated() {
+		if err := created.SetTerminated(RunStatus(status), time.UnixMilli(run.EndTime)); err != nil {
+			return "", err
+		}
+	}
+	return created.ID(), nil
+}
+
+func archiveTagValue(tags []archiveTag, key string) string {
+	for _, tag := range tags {
+		if tag.Key == key {
+			return tag.Val
+		}
+	}
+	return ""
+}
+
+func readArchiveJSON(name string, v interface{}) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("error reading archive: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error parsing %s: %w", filepath.Base(name), err)
+	}
+	return nil
+}
+
+// extractArchive extracts the regular files in the gzipped tar archive r into dir.
+func extractArchive(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid path %q in archive", header.Name)
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
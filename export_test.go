@@ -0,0 +1,162 @@
+package mlflow
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImport(t *testing.T) {
+	src, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := src.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	require.NoError(t, exp.SetTag("team", "vision"))
+	startTime := time.UnixMilli(1700000000000)
+	parent, err := exp.CreateRun("parent", WithStartTime(startTime))
+	require.NoError(t, err)
+	require.NoError(t, parent.LogBatch([]LoggedMetric{
+		{Key: "loss", Val: 2, Step: 0, Timestamp: startTime.Add(time.Second)},
+		{Key: "loss", Val: 1, Step: 1, Timestamp: startTime.Add(2 * time.Second)},
+	}, []Param{{Key: "lr", Val: "0.1"}}, nil))
+	localPath := filepath.Join(t.TempDir(), "model.bin")
+	require.NoError(t, os.WriteFile(localPath, []byte("weights"), 0644))
+	require.NoError(t, parent.LogArtifact(localPath, "checkpoints"))
+	require.NoError(t, parent.SetTerminated(RunStatusFinished, startTime.Add(time.Hour)))
+	child, err := exp.CreateRun("child", WithTags([]Tag{{Key: ParentRunIDTagKey, Val: parent.ID()}}))
+	require.NoError(t, err)
+	other, err := exp.CreateRun("other")
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	_, err = Export(src, &archive, ExportOptions{RunIDs: []string{"missing"}})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	archive.Reset()
+	exported, err := Export(src, &archive, ExportOptions{
+		ExperimentIDs: []string{exp.ID()},
+		RunIDs:        []string{parent.ID(), child.ID()},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{exp.ID()}, exported.ExperimentIDs)
+	assert.ElementsMatch(t, []string{parent.ID(), child.ID()}, exported.RunIDs)
+	assert.NotContains(t, exported.RunIDs, other.ID())
+
+	dst, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	imported, err := Import(dst, &archive)
+	require.NoError(t, err)
+	require.Len(t, imported.RunIDs, 2)
+
+	dstExp, err := dst.GetExperiment(imported.ExperimentIDs[exp.ID()])
+	require.NoError(t, err)
+	assert.Equal(t, "exp0", dstExp.Name())
+	team, err := dstExp.GetTag("team")
+	require.NoError(t, err)
+	assert.Equal(t, "vision", team)
+
+	newParent, err := dstExp.GetRun(imported.RunIDs[parent.ID()])
+	require.NoError(t, err)
+	info, err := newParent.Info()
+	require.NoError(t, err)
+	assert.Equal(t, "parent", info.Name)
+	assert.Equal(t, RunStatusFinished, info.Status)
+	assert.True(t, startTime.Equal(info.StartTime))
+	assert.True(t, startTime.Add(time.Hour).Equal(info.EndTime))
+	lr, err := newParent.GetParam("lr")
+	require.NoError(t, err)
+	assert.Equal(t, "0.1", lr)
+	history, _, err := newParent.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, LoggedMetric{Key: "loss", Val: 1, Step: 1, Timestamp: startTime.Add(2 * time.Second)}, history[1])
+	artifact, err := os.ReadFile(filepath.Join(newParent.(*fileRun).ArtifactDir(), "checkpoints", "model.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, "weights", string(artifact))
+	sourceID, err := newParent.GetTag(SourceRunIDTagKey)
+	require.NoError(t, err)
+	assert.Equal(t, parent.ID(), sourceID)
+
+	newChild, err := dstExp.GetRun(imported.RunIDs[child.ID()])
+	require.NoError(t, err)
+	parentID, err := newChild.GetTag(ParentRunIDTagKey)
+	require.NoError(t, err)
+	assert.Equal(t, newParent.ID(), parentID)
+	info, err = newChild.Info()
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusRunning, info.Status)
+}
+
+func TestExportSelectedRuns(t *testing.T) {
+	src, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp0, err := src.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	deleted, err := exp0.CreateRun("deleted")
+	require.NoError(t, err)
+	require.NoError(t, deleted.Delete())
+	exp1, err := src.GetOrCreateExperimentWithName("exp1")
+	require.NoError(t, err)
+	_, err = exp1.CreateRun("other")
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	exported, err := Export(src, &archive, ExportOptions{RunIDs: []string{deleted.ID()}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{exp0.ID()}, exported.ExperimentIDs)
+	assert.Equal(t, []string{deleted.ID()}, exported.RunIDs)
+
+	dst, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	imported, err := Import(dst, &archive)
+	require.NoError(t, err)
+	assert.Len(t, imported.ExperimentIDs, 1)
+	assert.Len(t, imported.RunIDs, 1)
+	exps, err := dst.ExperimentsByName()
+	require.NoError(t, err)
+	assert.NotContains(t, exps, "exp1")
+}
+
+// Diverged losses can't be JSON numbers, but must survive an export.
+func TestExportImportNonFiniteMetrics(t *testing.T) {
+	src, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := src.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	timestamp := time.UnixMilli(1700000000000)
+	metrics := []LoggedMetric{
+		{Key: "loss", Val: math.Inf(1), Step: 0, Timestamp: timestamp},
+		{Key: "loss", Val: math.NaN(), Step: 1, Timestamp: timestamp},
+		{Key: "grad", Val: math.Inf(-1), Step: 0, Timestamp: timestamp},
+	}
+	require.NoError(t, run.LogBatch(metrics, nil, nil))
+
+	var archive bytes.Buffer
+	_, err = Export(src, &archive, ExportOptions{})
+	require.NoError(t, err)
+	dst, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	imported, err := Import(dst, &archive)
+	require.NoError(t, err)
+	dstExp, err := dst.GetExperiment(imported.ExperimentIDs[exp.ID()])
+	require.NoError(t, err)
+	newRun, err := dstExp.GetRun(imported.RunIDs[run.ID()])
+	require.NoError(t, err)
+	loss, _, err := newRun.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, loss, 2)
+	assert.True(t, math.IsInf(loss[0].Val, 1))
+	assert.True(t, math.IsNaN(loss[1].Val))
+	grad, _, err := newRun.GetMetricHistory("grad", "")
+	require.NoError(t, err)
+	require.Len(t, grad, 1)
+	assert.True(t, math.IsInf(grad[0].Val, -1))
+}
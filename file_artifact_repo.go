@@ -1,32 +1,123 @@
 package mlflow
 
 import (
+	"context"
+	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+
+	"github.com/Astera-org/mlflow-go/protos"
 )
 
-// FileArtifactRepo writes to a local file system.
+// FileArtifactRepo reads and writes a local file system.
 // Generally it is used indirectly via [Run.LogArtifact].
 type FileArtifactRepo struct {
 	rootDir string
+	ctx     context.Context
 }
 
 func NewFileArtifactRepo(rootDir string) (ArtifactRepo, error) {
-	return &FileArtifactRepo{rootDir: rootDir}, nil
+	return &FileArtifactRepo{rootDir: rootDir, ctx: context.Background()}, nil
+}
+
+// Implements [ArtifactRepo.WithContext].
+func (repo *FileArtifactRepo) WithContext(ctx context.Context) ArtifactRepo {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *repo
+	c.ctx = ctx
+	return &c
 }
 
+// Implements [ArtifactRepo.LogArtifact].
+// If localPath is a directory, its tree is logged under artifactPath/<base name of localPath>.
 func (repo *FileArtifactRepo) LogArtifact(localPath, artifactPath string) error {
-	if artifactPath == "" {
-		artifactPath = filepath.Base(localPath)
+	if err := repo.ctx.Err(); err != nil {
+		return err
 	}
-	err := os.Link(localPath, filepath.Join(repo.rootDir, artifactPath))
+	destDir := filepath.Join(repo.rootDir, filepath.FromSlash(artifactPath))
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+	destPath := filepath.Join(destDir, filepath.Base(localPath))
+	err := os.Link(localPath, destPath)
 	if err != nil {
-		err = exec.Command("cp", "-r", localPath, filepath.Join(repo.rootDir, artifactPath)).Run()
+		err = exec.CommandContext(repo.ctx, "cp", "-r", localPath, destPath).Run()
 	}
 	return err
 }
 
+// Implements [ArtifactRepo.LogArtifacts].
 func (repo *FileArtifactRepo) LogArtifacts(localPath, artifactPath string) error {
-	return repo.LogArtifact(localPath, artifactPath)
+	if err := repo.ctx.Err(); err != nil {
+		return err
+	}
+	destDir := filepath.Join(repo.rootDir, filepath.FromSlash(artifactPath))
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+	// Copy the contents of localPath rather than the directory itself.
+	return exec.CommandContext(repo.ctx, "cp", "-r", localPath+string(filepath.Separator)+".", destDir).Run()
+}
+
+// Implements [ArtifactRepo.ListArtifacts].
+func (repo *FileArtifactRepo) ListArtifacts(artifactPath string) ([]ArtifactInfo, error) {
+	if err := repo.ctx.Err(); err != nil {
+		return nil, err
+	}
+	dirPath := cleanArtifactPath(artifactPath)
+	dir := filepath.Join(repo.rootDir, filepath.FromSlash(dirPath))
+	if info, err := os.Stat(dir); os.IsNotExist(err) || (err == nil && !info.IsDir()) {
+		return []ArtifactInfo{}, nil
+	}
+	// Sorted by name, so also by path.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]ArtifactInfo, 0, len(entries))
+	for _, entry := range entries {
+		info := ArtifactInfo{Path: path.Join(dirPath, entry.Name()), IsDir: entry.IsDir()}
+		if !entry.IsDir() {
+			entryInfo, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+			info.Size = entryInfo.Size()
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// Implements [ArtifactRepo.DownloadArtifacts].
+func (repo *FileArtifactRepo) DownloadArtifacts(artifactPath, dstDir string) (string, error) {
+	return DownloadArtifacts(repo, artifactPath, dstDir)
+}
+
+// Implements [ArtifactRepo.OpenArtifact].
+func (repo *FileArtifactRepo) OpenArtifact(artifactPath string) (io.ReadCloser, error) {
+	if err := repo.ctx.Err(); err != nil {
+		return nil, err
+	}
+	artifactPath = cleanArtifactPath(artifactPath)
+	f, err := os.Open(filepath.Join(repo.rootDir, filepath.FromSlash(artifactPath)))
+	if os.IsNotExist(err) {
+		return nil, notFoundArtifact(artifactPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err == nil && info.IsDir() {
+		err = newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "artifact %s is a directory", artifactPath)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
 }
@@ -1,13 +1,16 @@
 package mlflow
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/Astera-org/mlflow-go/protos"
 	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 )
@@ -24,15 +27,124 @@ type experimentMeta struct {
 type fileExperiment struct {
 	experimentMeta
 	rootDir string
+	// The root directory of the FileStore containing this experiment.
+	storeDir string
+	ctx      context.Context
 }
 
 func (exp *fileExperiment) ID() string {
 	return exp.ExperimentID
 }
 
-func (exp *fileExperiment) CreateRun(name string) (Run, error) {
+// Implements [Experiment.WithContext].
+func (exp *fileExperiment) WithContext(ctx context.Context) Experiment {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *exp
+	c.ctx = ctx
+	return &c
+}
+
+// store returns the FileStore containing this experiment.
+func (exp *fileExperiment) store() *FileStore {
+	return &FileStore{rootDir: exp.storeDir, ctx: exp.ctx}
+}
+
+func (exp *fileExperiment) Name() string {
+	return exp.experimentMeta.Name
+}
+
+// Implements [Experiment.SetName].
+func (exp *fileExperiment) SetName(name string) error {
+	if exp.LifecycleStage != LifecycleStageActive {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "cannot rename experiment %s because it is not active", exp.Name())
+	}
+	if name == "" {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "experiment name must not be empty")
+	}
+	expsByName, err := exp.store().ExperimentsByName(WithViewType(ViewTypeAll))
+	if err != nil {
+		return err
+	}
+	if other, ok := expsByName[name]; ok && other.ID() != exp.ExperimentID {
+		return newError(protos.ErrorCode_RESOURCE_ALREADY_EXISTS, "experiment with name %q already exists", name)
+	}
+	exp.experimentMeta.Name = name
+	return exp.syncMeta()
+}
+
+func (exp *fileExperiment) tagPath(key string) string {
+	return filepath.Join(exp.rootDir, tagsFolderName, key)
+}
+
+// Implements [Experiment.SetTag].
+// Like the Python client, tags are stored as files in the experiment's tags directory.
+func (exp *fileExperiment) SetTag(key, value string) error {
+	if exp.LifecycleStage != LifecycleStageActive {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "cannot set tag on experiment %s because it is not active", exp.Name())
+	}
+	path := exp.tagPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(value), 0644)
+}
+
+// Implements [Experiment.GetTag].
+func (exp *fileExperiment) GetTag(key string) (string, error) {
+	valBytes, err := os.ReadFile(exp.tagPath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no tag with key %s", key)
+		}
+		return "", err
+	}
+	return string(valBytes), nil
+}
+
+// Implements [Experiment.DeleteTag].
+func (exp *fileExperiment) DeleteTag(key string) error {
+	err := os.Remove(exp.tagPath(key))
+	if os.IsNotExist(err) {
+		return newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no tag with key %s", key)
+	}
+	return err
+}
+
+// Implements [Experiment.Info].
+func (exp *fileExperiment) Info() (ExperimentInfo, error) {
+	metaBytes, err := os.ReadFile(filepath.Join(exp.rootDir, metaDataFileName))
+	if err != nil {
+		return ExperimentInfo{}, err
+	}
+	if err = yaml.Unmarshal(metaBytes, &exp.experimentMeta); err != nil {
+		return ExperimentInfo{}, err
+	}
+	tags, err := readKeyValueDir(filepath.Join(exp.rootDir, tagsFolderName))
+	if err != nil {
+		return ExperimentInfo{}, err
+	}
+	info := ExperimentInfo{
+		ID:               exp.ExperimentID,
+		Name:             exp.experimentMeta.Name,
+		ArtifactLocation: exp.ArtifactLocation,
+		LifecycleStage:   exp.LifecycleStage,
+		CreationTime:     timeFromMillis(exp.CreationTime),
+		LastUpdateTime:   timeFromMillis(exp.LastUpdateTime),
+		Tags:             make([]Tag, 0, len(tags)),
+	}
+	for key, val := range tags {
+		info.Tags = append(info.Tags, Tag{Key: key, Val: val})
+	}
+	sort.Slice(info.Tags, func(i, j int) bool { return info.Tags[i].Key < info.Tags[j].Key })
+	return info, nil
+}
+
+func (exp *fileExperiment) CreateRun(name string, opts ...CreateRunOption) (Run, error) {
+	options := newCreateRunOptions(opts)
 	if exp.LifecycleStage != LifecycleStageActive {
-		return nil, fmt.Errorf("experiment %s is not active", exp.Name)
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "experiment %s is not active", exp.Name())
 	}
 	runID := strings.ReplaceAll(uuid.NewString(), "-", "")
 	if name == "" {
@@ -49,8 +161,8 @@ func (exp *fileExperiment) CreateRun(name string) (Run, error) {
 		ExperimentID:   exp.ExperimentID,
 		LifecycleStage: LifecycleStageActive,
 		RunName:        name,
-		StartTime:      time.Now().UnixMilli(),
-		Status:         runStatusRunning,
+		StartTime:      options.startTime.UnixMilli(),
+		Status:         RunStatusRunning,
 		UserID:         userName,
 		RunID:          runID,
 		RunUUID:        runID,
@@ -58,6 +170,7 @@ func (exp *fileExperiment) CreateRun(name string) (Run, error) {
 	run := &fileRun{
 		runMeta: runMeta,
 		rootDir: filepath.Join(exp.rootDir, runID),
+		ctx:     exp.ctx,
 	}
 	for _, subDir := range []string{artifactsFolderName, metricsFolderName, paramsFolderName, tagsFolderName} {
 		if err = os.MkdirAll(filepath.Join(run.rootDir, subDir), 0755); err != nil {
@@ -67,6 +180,9 @@ func (exp *fileExperiment) CreateRun(name string) (Run, error) {
 	if err = run.SetTag(UserTagKey, userName); err != nil {
 		return nil, err
 	}
+	if err = run.SetTags(options.tags); err != nil {
+		return nil, err
+	}
 
 	run.syncMeta()
 	return run, nil
@@ -74,11 +190,14 @@ func (exp *fileExperiment) CreateRun(name string) (Run, error) {
 
 func (exp *fileExperiment) GetRun(runID string) (Run, error) {
 	if runID == "" {
-		return nil, fmt.Errorf("runID is empty")
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "runID is empty")
 	}
 	metaPath := filepath.Join(exp.rootDir, runID, metaDataFileName)
 	metaBytes, err := os.ReadFile(metaPath)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no run with id %s in experiment %s", runID, exp.ExperimentID)
+		}
 		return nil, err
 	}
 	runMeta := &runMeta{}
@@ -88,9 +207,33 @@ func (exp *fileExperiment) GetRun(runID string) (Run, error) {
 	return &fileRun{
 		runMeta: *runMeta,
 		rootDir: filepath.Join(exp.rootDir, runID),
+		ctx:     exp.ctx,
 	}, nil
 }
 
+// runs reads all of the runs in the experiment.
+func (exp *fileExperiment) runs() ([]*fileRun, error) {
+	files, err := os.ReadDir(exp.rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("mlflow.fileExperiment: error reading dir: %w", err)
+	}
+	runs := make([]*fileRun, 0, len(files))
+	for _, file := range files {
+		if err := exp.ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !file.IsDir() || file.Name() == tagsFolderName {
+			continue
+		}
+		run, err := exp.GetRun(file.Name())
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run.(*fileRun))
+	}
+	return runs, nil
+}
+
 // Writes ExperimentMeta to disk
 func (exp *fileExperiment) syncMeta() error {
 	exp.experimentMeta.LastUpdateTime = time.Now().UnixMilli()
@@ -100,3 +243,43 @@ func (exp *fileExperiment) syncMeta() error {
 	}
 	return os.WriteFile(filepath.Join(exp.rootDir, metaDataFileName), metaBytes, 0644)
 }
+
+// Implements [Experiment.Delete].
+// Like the Python client, this moves the experiment to the trash directory.
+func (exp *fileExperiment) Delete() error {
+	if exp.LifecycleStage == LifecycleStageDeleted {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "experiment %s is already deleted", exp.Name())
+	}
+	trashDir := filepath.Join(exp.storeDir, trashFolderName)
+	if err := os.MkdirAll(trashDir, 0755); err != nil {
+		return fmt.Errorf("mlflow.fileExperiment.Delete: error creating trash dir: %w", err)
+	}
+	return exp.move(filepath.Join(trashDir, exp.ExperimentID), LifecycleStageDeleted)
+}
+
+// Implements [Experiment.Restore].
+func (exp *fileExperiment) Restore() error {
+	if exp.LifecycleStage != LifecycleStageDeleted {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "experiment %s is not deleted", exp.Name())
+	}
+	expsByName, err := exp.store().ExperimentsByName()
+	if err != nil {
+		return err
+	}
+	if _, ok := expsByName[exp.Name()]; ok {
+		return newError(protos.ErrorCode_RESOURCE_ALREADY_EXISTS, "cannot restore experiment %s because an active experiment with the same name exists", exp.Name())
+	}
+	return exp.move(filepath.Join(exp.storeDir, exp.ExperimentID), LifecycleStageActive)
+}
+
+func (exp *fileExperiment) move(newDir, lifecycleStage string) error {
+	if _, err := os.Stat(newDir); err == nil {
+		return newError(protos.ErrorCode_RESOURCE_ALREADY_EXISTS, "cannot move experiment %s to %s because it already exists", exp.Name(), newDir)
+	}
+	if err := os.Rename(exp.rootDir, newDir); err != nil {
+		return fmt.Errorf("mlflow.fileExperiment: error moving experiment: %w", err)
+	}
+	exp.rootDir = newDir
+	exp.LifecycleStage = lifecycleStage
+	return exp.syncMeta()
+}
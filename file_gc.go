@@ -0,0 +1,211 @@
+package mlflow
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+// GarbageCollectOptions configures [FileStore.GarbageCollect].
+type GarbageCollectOptions struct {
+	// Only runs and experiments deleted before this time are removed.
+	// If zero, all deleted runs and experiments are eligible.
+	DeletedBefore time.Time
+	// If set, only these runs are removed. They must all be deleted.
+	// If only ExperimentIDs is set, no runs outside of those experiments are removed.
+	RunIDs []string
+	// If set, only these experiments are removed. They must all be deleted.
+	// If only RunIDs is set, no experiments are removed.
+	ExperimentIDs []string
+	// If true, nothing is removed, but the result reports what would have been.
+	DryRun bool
+}
+
+// GarbageCollectResult reports what [FileStore.GarbageCollect] removed.
+type GarbageCollectResult struct {
+	RunIDs        []string
+	ExperimentIDs []string
+	// The number of bytes freed on disk. Files that have hard links outside of
+	// the removed directories are not counted, since removing them frees nothing.
+	BytesReclaimed int64
+}
+
+// GarbageCollect permanently removes deleted runs and experiments, including their artifacts.
+// Based on `mlflow gc`:
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/cli.py#L506
+func (s *FileStore) GarbageCollect(opts GarbageCollectOptions) (GarbageCollectResult, error) {
+	var result GarbageCollectResult
+	exps, err := s.readExperiments(s.rootDir)
+	if err != nil {
+		return result, err
+	}
+	deletedExps, err := s.readExperiments(s.trashDir())
+	if err != nil {
+		return result, err
+	}
+	isOldEnough := func(deletedMillis int64) bool {
+		return opts.DeletedBefore.IsZero() || deletedMillis < opts.DeletedBefore.UnixMilli()
+	}
+
+	expsToRemove := make([]*fileExperiment, 0)
+	if len(opts.ExperimentIDs) > 0 || len(opts.RunIDs) == 0 {
+		wanted, missing := stringSet(opts.ExperimentIDs), stringSet(opts.ExperimentIDs)
+		for _, exp := range deletedExps {
+			if len(wanted) > 0 && !wanted[exp.ExperimentID] {
+				continue
+			}
+			delete(missing, exp.ExperimentID)
+			if !isOldEnough(exp.LastUpdateTime) {
+				if len(opts.ExperimentIDs) > 0 {
+					return result, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "experiment %s was deleted too recently to be removed", exp.ExperimentID)
+				}
+				continue
+			}
+			expsToRemove = append(expsToRemove, exp)
+		}
+		for id := range missing {
+			return result, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "experiment %s does not exist or is not deleted", id)
+		}
+	}
+
+	// Runs in removed experiments are removed along with the experiment.
+	runsToRemove := make([]*fileRun, 0)
+	if len(opts.RunIDs) > 0 || len(opts.ExperimentIDs) == 0 {
+		wanted, missing := stringSet(opts.RunIDs), stringSet(opts.RunIDs)
+		removedExpIDs := make(map[string]bool)
+		for _, exp := range expsToRemove {
+			removedExpIDs[exp.ExperimentID] = true
+		}
+		for _, exp := range append(exps, deletedExps...) {
+			expRuns, err := exp.runs()
+			if err != nil {
+				return result, err
+			}
+			for _, run := range expRuns {
+				if len(wanted) > 0 && !wanted[run.RunID] {
+					continue
+				}
+				delete(missing, run.RunID)
+				if removedExpIDs[exp.ExperimentID] {
+					continue
+				}
+				if run.LifecycleStage != LifecycleStageDeleted {
+					if len(opts.RunIDs) > 0 {
+						return result, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "run %s is not deleted", run.RunID)
+					}
+					continue
+				}
+				if !isOldEnough(run.DeletedTime) {
+					if len(opts.RunIDs) > 0 {
+						return result, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "run %s was deleted too recently to be removed", run.RunID)
+					}
+					continue
+				}
+				runsToRemove = append(runsToRemove, run)
+			}
+		}
+		for id := range missing {
+			return result, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "run %s does not exist", id)
+		}
+	}
+
+	dirsToRemove := make([]string, 0, len(expsToRemove)+len(runsToRemove))
+	for _, exp := range expsToRemove {
+		result.ExperimentIDs = append(result.ExperimentIDs, exp.ExperimentID)
+		dirsToRemove = append(dirsToRemove, exp.rootDir)
+		expRuns, err := exp.runs()
+		if err != nil {
+			return result, err
+		}
+		for _, run := range expRuns {
+			result.RunIDs = append(result.RunIDs, run.RunID)
+			dirsToRemove = append(dirsToRemove, externalArtifactDir(run)...)
+		}
+	}
+	for _, run := range runsToRemove {
+		result.RunIDs = append(result.RunIDs, run.RunID)
+		dirsToRemove = append(dirsToRemove, run.rootDir)
+		dirsToRemove = append(dirsToRemove, externalArtifactDir(run)...)
+	}
+
+	if result.BytesReclaimed, err = reclaimableBytes(dirsToRemove); err != nil {
+		return result, err
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+	for _, dir := range dirsToRemove {
+		if err := os.RemoveAll(dir); err != nil {
+			return result, fmt.Errorf("mlflow.FileStore.GarbageCollect: error removing %s: %w", dir, err)
+		}
+	}
+	return result, nil
+}
+
+func stringSet(strs []string) map[string]bool {
+	set := make(map[string]bool, len(strs))
+	for _, s := range strs {
+		set[s] = true
+	}
+	return set
+}
+
+// externalArtifactDir returns the run's artifact directory if it is on the local file system
+// but outside of the run's directory, which happens if the experiment has a custom artifact location.
+func externalArtifactDir(run *fileRun) []string {
+	parsed, err := url.Parse(run.ArtifactURI)
+	if err != nil || (parsed.Scheme != "file" && parsed.Scheme != "") || parsed.Path == "" {
+		return nil
+	}
+	dir := filepath.FromSlash(parsed.Path)
+	rel, err := filepath.Rel(run.rootDir, dir)
+	if err == nil && !strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil
+	}
+	return []string{dir}
+}
+
+// reclaimableBytes returns the number of bytes that would be freed by removing dirs.
+func reclaimableBytes(dirs []string) (int64, error) {
+	var total int64
+	// Number of links to each hard-linked file seen so far.
+	linksSeen := make(map[fileID]uint64)
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			id, numLinks, ok := hardLinkInfo(info)
+			if !ok || numLinks <= 1 {
+				total += info.Size()
+				return nil
+			}
+			// Only count the file once all of its links have been seen.
+			linksSeen[id]++
+			if linksSeen[id] == numLinks {
+				total += info.Size()
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+	}
+	return total, nil
+}
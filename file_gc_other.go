@@ -0,0 +1,12 @@
+//go:build !unix
+
+package mlflow
+
+import "os"
+
+type fileID struct{}
+
+// hardLinkInfo is not implemented on this platform, so all files are assumed to have a single link.
+func hardLinkInfo(info os.FileInfo) (fileID, uint64, bool) {
+	return fileID{}, 0, false
+}
@@ -0,0 +1,85 @@
+package mlflow
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGarbageCollect(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp0, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	kept, err := exp0.CreateRun("kept")
+	require.NoError(t, err)
+	deleted, err := exp0.CreateRun("deleted")
+	require.NoError(t, err)
+
+	artifactPath := filepath.Join(t.TempDir(), "artifact.txt")
+	require.NoError(t, os.WriteFile(artifactPath, []byte("hello"), 0644))
+	// Hard linked into the run's artifacts, so removing it frees nothing.
+	require.NoError(t, deleted.LogArtifact(artifactPath, ""))
+	linkedInfo, err := os.Stat(filepath.Join(deleted.(*fileRun).ArtifactDir(), "artifact.txt"))
+	require.NoError(t, err)
+	artifactInfo, err := os.Stat(artifactPath)
+	require.NoError(t, err)
+	require.True(t, os.SameFile(linkedInfo, artifactInfo))
+	require.NoError(t, deleted.Delete())
+
+	exp1, err := fs.GetOrCreateExperimentWithName("exp1")
+	require.NoError(t, err)
+	_, err = exp1.CreateRun("in deleted experiment")
+	require.NoError(t, err)
+	require.NoError(t, exp1.Delete())
+
+	// Not deleted long enough ago.
+	result, err := fs.GarbageCollect(GarbageCollectOptions{DeletedBefore: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, result.RunIDs)
+	assert.Empty(t, result.ExperimentIDs)
+
+	_, err = fs.GarbageCollect(GarbageCollectOptions{RunIDs: []string{kept.ID()}})
+	assert.Error(t, err)
+	_, err = fs.GarbageCollect(GarbageCollectOptions{ExperimentIDs: []string{exp0.ID()}})
+	assert.Error(t, err)
+
+	result, err = fs.GarbageCollect(GarbageCollectOptions{RunIDs: []string{deleted.ID()}, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{deleted.ID()}, result.RunIDs)
+	assert.Empty(t, result.ExperimentIDs)
+	assert.Equal(t, runFilesSize(t, deleted.(*fileRun).rootDir)-int64(len("hello")), result.BytesReclaimed)
+	_, err = exp0.GetRun(deleted.ID())
+	require.NoError(t, err)
+
+	result, err = fs.GarbageCollect(GarbageCollectOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.RunIDs, 2)
+	assert.Equal(t, []string{exp1.ID()}, result.ExperimentIDs)
+	_, err = exp0.GetRun(deleted.ID())
+	assert.Error(t, err)
+	_, err = exp0.GetRun(kept.ID())
+	assert.NoError(t, err)
+	_, err = fs.GetExperiment(exp1.ID())
+	assert.Error(t, err)
+	assert.FileExists(t, artifactPath)
+}
+
+// runFilesSize returns the total size of the regular files under dir.
+func runFilesSize(t *testing.T, dir string) int64 {
+	var total int64
+	require.NoError(t, filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		info, err := entry.Info()
+		total += info.Size()
+		return err
+	}))
+	return total
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package mlflow
+
+import (
+	"os"
+	"syscall"
+)
+
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// hardLinkInfo returns a unique ID for the file and its number of hard links.
+func hardLinkInfo(info os.FileInfo) (fileID, uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, 0, false
+	}
+	return fileID{uint64(stat.Dev), uint64(stat.Ino)}, uint64(stat.Nlink), true
+}
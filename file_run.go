@@ -1,12 +1,19 @@
 package mlflow
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Astera-org/mlflow-go/protos"
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,6 +21,8 @@ type runMeta struct {
 	ArtifactURI    string `yaml:"artifact_uri"`
 	ExperimentID   string `yaml:"experiment_id"`
 	LifecycleStage string `yaml:"lifecycle_stage"`
+	// Unix timestamp in milliseconds of when the run was deleted. Only set for deleted runs.
+	DeletedTime    int64  `yaml:"deleted_time,omitempty"`
 	EndTime        int64  `yaml:"end_time"`
 	EntryPointName string `yaml:"entry_point_name"`
 	RunID          string `yaml:"run_id"`
@@ -25,25 +34,58 @@ type runMeta struct {
 	SourceVersion string `yaml:"source_version"`
 	StartTime     int64  `yaml:"start_time"`
 	// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/protos/service.proto#L439
-	Status int      `yaml:"status"`
-	Tags   []string `yaml:"tags"`
-	UserID string   `yaml:"user_id"`
+	Status RunStatus `yaml:"status"`
+	Tags   []string  `yaml:"tags"`
+	UserID string    `yaml:"user_id"`
 }
 
 type fileRun struct {
 	runMeta
 	rootDir string
+	ctx     context.Context
 }
 
 func (r *fileRun) ID() string {
 	return r.RunID
 }
 
+// Implements [Run.WithContext].
+func (r *fileRun) WithContext(ctx context.Context) Run {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *r
+	c.ctx = ctx
+	return &c
+}
+
 func (r *fileRun) UIURL() string {
 	// Assumes UI is running on default port.
 	return fmt.Sprintf("http://127.0.0.1:5000/#/experiments/%s/runs/%s", r.runMeta.ExperimentID, r.RunID)
 }
 
+// Implements [Run.Info].
+func (r *fileRun) Info() (RunInfo, error) {
+	metaBytes, err := os.ReadFile(filepath.Join(r.rootDir, metaDataFileName))
+	if err != nil {
+		return RunInfo{}, err
+	}
+	if err = yaml.Unmarshal(metaBytes, &r.runMeta); err != nil {
+		return RunInfo{}, err
+	}
+	return RunInfo{
+		ID:             r.RunID,
+		Name:           r.RunName,
+		ExperimentID:   r.runMeta.ExperimentID,
+		UserID:         r.UserID,
+		Status:         r.Status,
+		StartTime:      timeFromMillis(r.StartTime),
+		EndTime:        timeFromMillis(r.EndTime),
+		ArtifactURI:    r.ArtifactURI,
+		LifecycleStage: r.LifecycleStage,
+	}, nil
+}
+
 func (r *fileRun) syncMeta() error {
 	metaBytes, err := yaml.Marshal(r.runMeta)
 	if err != nil {
@@ -68,29 +110,67 @@ func (r *fileRun) SetTags(tags []Tag) error {
 func (r *fileRun) GetTag(key string) (string, error) {
 	valBytes, err := os.ReadFile(filepath.Join(r.rootDir, tagsFolderName, key))
 	if err != nil {
+		if os.IsNotExist(err) {
+			return "", newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no tag with key %s", key)
+		}
 		return "", err
 	}
 	return string(valBytes), nil
 }
 
+// Implements [Run.DeleteTag].
+func (r *fileRun) DeleteTag(key string) error {
+	if r.LifecycleStage != LifecycleStageActive {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "run %s is not active", r.RunName)
+	}
+	err := os.Remove(filepath.Join(r.rootDir, tagsFolderName, key))
+	if os.IsNotExist(err) {
+		return newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no tag with key %s", key)
+	}
+	return err
+}
+
 func (r *fileRun) ArtifactDir() string {
 	return filepath.Join(r.rootDir, artifactsFolderName)
 }
 
+func (r *fileRun) artifactRepo() ArtifactRepo {
+	return &FileArtifactRepo{rootDir: r.ArtifactDir(), ctx: r.ctx}
+}
+
 func (r *fileRun) LogArtifact(localPath, artifactPath string) error {
-	repo, err := NewFileArtifactRepo(r.ArtifactDir())
-	if err != nil {
-		return err
-	}
-	return repo.LogArtifact(localPath, artifactPath)
+	return r.artifactRepo().LogArtifact(localPath, artifactPath)
+}
+
+func (r *fileRun) logArtifacts(localDir, artifactPath string) error {
+	return r.artifactRepo().LogArtifacts(localDir, artifactPath)
+}
+
+// Implements [Run.ListArtifacts].
+func (r *fileRun) ListArtifacts(artifactPath string) ([]ArtifactInfo, error) {
+	return r.artifactRepo().ListArtifacts(artifactPath)
+}
+
+// Implements [Run.DownloadArtifacts].
+func (r *fileRun) DownloadArtifacts(artifactPath, dstDir string) (string, error) {
+	return r.artifactRepo().DownloadArtifacts(artifactPath, dstDir)
+}
+
+// Implements [Run.OpenArtifact].
+func (r *fileRun) OpenArtifact(artifactPath string) (io.ReadCloser, error) {
+	return r.artifactRepo().OpenArtifact(artifactPath)
 }
 
 func (r *fileRun) LogMetric(key string, val float64, step int64) error {
+	return r.logMetric(key, val, step, time.Now())
+}
+
+func (r *fileRun) logMetric(key string, val float64, step int64, timestamp time.Time) error {
 	if r.LifecycleStage != LifecycleStageActive {
-		return fmt.Errorf("run %s is not active", r.RunName)
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "run %s is not active", r.RunName)
 	}
-	if r.Status != runStatusRunning {
-		return fmt.Errorf("run %s is not running", r.RunName)
+	if r.Status != RunStatusRunning {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "run %s is not running", r.RunName)
 	}
 	path := filepath.Join(r.rootDir, metricsFolderName, key)
 	// If the file doesn't exist, create it, or append to the file
@@ -98,7 +178,7 @@ func (r *fileRun) LogMetric(key string, val float64, step int64) error {
 	if err != nil {
 		return err
 	}
-	line := fmt.Sprintf("%d %g %d\n", time.Now().UnixMilli(), val, step)
+	line := fmt.Sprintf("%d %g %d\n", timestamp.UnixMilli(), val, step)
 	if _, err := f.Write([]byte(line)); err != nil {
 		f.Close() // ignore error; Write error takes precedence
 		return err
@@ -115,6 +195,52 @@ func (r *fileRun) LogMetrics(metrics []Metric, step int64) error {
 	return nil
 }
 
+// Implements [Run.LogBatch].
+func (r *fileRun) LogBatch(metrics []LoggedMetric, params []Param, tags []Tag) error {
+	now := time.Now()
+	for _, m := range metrics {
+		timestamp := m.Timestamp
+		if timestamp.IsZero() {
+			timestamp = now
+		}
+		if err := r.logMetric(m.Key, m.Val, m.Step, timestamp); err != nil {
+			return err
+		}
+	}
+	if err := r.LogParams(params); err != nil {
+		return err
+	}
+	return r.SetTags(tags)
+}
+
+// Implements [Run.Params].
+func (r *fileRun) Params() ([]Param, error) {
+	params, err := readKeyValueDir(filepath.Join(r.rootDir, paramsFolderName))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Param, 0, len(params))
+	for key, val := range params {
+		result = append(result, Param{Key: key, Val: val})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// Implements [Run.Tags].
+func (r *fileRun) Tags() ([]Tag, error) {
+	tags, err := readKeyValueDir(filepath.Join(r.rootDir, tagsFolderName))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Tag, 0, len(tags))
+	for key, val := range tags {
+		result = append(result, Tag{Key: key, Val: val})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
 func (r *fileRun) paramPath(key string) string {
 	return filepath.Join(r.rootDir, paramsFolderName, key)
 }
@@ -133,18 +259,17 @@ func (r *fileRun) LogParams(params []Param) error {
 }
 
 func (r *fileRun) End() error {
-	r.EndTime = time.Now().UnixMilli()
-	r.Status = runStatusFinished
-	if err := r.syncMeta(); err != nil {
-		return err
-	}
-	endIfActive(r)
-	return nil
+	return r.SetTerminated(RunStatusFinished, time.Now())
 }
 
 func (r *fileRun) Fail() error {
-	r.EndTime = time.Now().UnixMilli()
-	r.Status = runStatusFailed
+	return r.SetTerminated(RunStatusFailed, time.Now())
+}
+
+// Implements [Run.SetTerminated].
+func (r *fileRun) SetTerminated(status RunStatus, endTime time.Time) error {
+	r.EndTime = endTime.UnixMilli()
+	r.Status = status
 	if err := r.syncMeta(); err != nil {
 		return err
 	}
@@ -152,6 +277,26 @@ func (r *fileRun) Fail() error {
 	return nil
 }
 
+// Implements [Run.Delete].
+func (r *fileRun) Delete() error {
+	if r.LifecycleStage != LifecycleStageActive {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "run %s is not active", r.RunName)
+	}
+	r.LifecycleStage = LifecycleStageDeleted
+	r.DeletedTime = time.Now().UnixMilli()
+	return r.syncMeta()
+}
+
+// Implements [Run.Restore].
+func (r *fileRun) Restore() error {
+	if r.LifecycleStage != LifecycleStageDeleted {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "run %s is not deleted", r.RunName)
+	}
+	r.LifecycleStage = LifecycleStageActive
+	r.DeletedTime = 0
+	return r.syncMeta()
+}
+
 func (r *fileRun) ExperimentID() string {
 	return r.runMeta.ExperimentID
 }
@@ -168,7 +313,7 @@ func (r *fileRun) Name() string {
 func (r *fileRun) GetParam(key string) (string, error) {
 	f, err := os.Open(r.paramPath(key))
 	if err != nil {
-		return "", fmt.Errorf("param with key %s not found", key)
+		return "", newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no param with key %s", key)
 	}
 	defer f.Close()
 	valBytes, err := io.ReadAll(f)
@@ -177,3 +322,126 @@ func (r *fileRun) GetParam(key string) (string, error) {
 	}
 	return string(valBytes), nil
 }
+
+// readKeyValueDir reads a directory of files where each file name is a key
+// and the contents are the value, as used for params and tags.
+// Keys may contain slashes, in which case the files are in sub-directories.
+func readKeyValueDir(dir string) (map[string]string, error) {
+	kvs := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		valBytes, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		key, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		kvs[filepath.ToSlash(key)] = string(valBytes)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return kvs, nil
+}
+
+// readMetricHistory reads all of the values logged for a metric.
+// Each line of the file is "<timestamp> <value> <step>".
+func readMetricHistory(path, key string) ([]LoggedMetric, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	history := make([]LoggedMetric, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric timestamp in %s: %w", path, err)
+		}
+		val, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric value in %s: %w", path, err)
+		}
+		var step int64
+		if len(fields) > 2 {
+			if step, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid metric step in %s: %w", path, err)
+			}
+		}
+		history = append(history, LoggedMetric{Key: key, Val: val, Step: step, Timestamp: time.UnixMilli(timestamp)})
+	}
+	return history, scanner.Err()
+}
+
+// latestMetric returns the value with the highest (step, timestamp, value),
+// which is what MLFlow considers the latest value.
+func latestMetric(history []LoggedMetric) (LoggedMetric, bool) {
+	var latest LoggedMetric
+	for i, m := range history {
+		if i == 0 || m.Step > latest.Step ||
+			(m.Step == latest.Step && (m.Timestamp.After(latest.Timestamp) ||
+				(m.Timestamp.Equal(latest.Timestamp) && m.Val > latest.Val))) {
+			latest = m
+		}
+	}
+	return latest, len(history) > 0
+}
+
+// Implements [Run.GetMetricHistory].
+func (r *fileRun) GetMetricHistory(key, pageToken string) ([]LoggedMetric, string, error) {
+	history, err := readMetricHistory(filepath.Join(r.rootDir, metricsFolderName, key), key)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []LoggedMetric{}, "", nil
+		}
+		return nil, "", err
+	}
+	start, end, nextPageToken, err := paginate(len(history), pageToken, metricHistoryMaxResults)
+	if err != nil {
+		return nil, "", newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "%v", err)
+	}
+	return history[start:end], nextPageToken, nil
+}
+
+// Implements [Run.GetLatestMetrics].
+func (r *fileRun) GetLatestMetrics() ([]LoggedMetric, error) {
+	metrics := make([]LoggedMetric, 0)
+	metricsDir := filepath.Join(r.rootDir, metricsFolderName)
+	err := filepath.WalkDir(metricsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		key, err := filepath.Rel(metricsDir, path)
+		if err != nil {
+			return err
+		}
+		history, err := readMetricHistory(path, filepath.ToSlash(key))
+		if err != nil {
+			return err
+		}
+		if latest, ok := latestMetric(history); ok {
+			metrics = append(metrics, latest)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return metrics, nil
+}
@@ -1,13 +1,14 @@
 package mlflow
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"time"
 
+	"github.com/Astera-org/mlflow-go/protos"
 	"gopkg.in/yaml.v3"
 )
 
@@ -23,18 +24,12 @@ const (
 	// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/entities/lifecycle_stage.py#L5
 	LifecycleStageActive  = "active"
 	LifecycleStageDeleted = "deleted"
-
-	// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/protos/service.proto#L439
-	runStatusRunning   = 1
-	runStatusScheduled = 2
-	runStatusFinished  = 3
-	runStatusFailed    = 4
-	runStatusKilled    = 5
 )
 
 // Implements Tracking interface
 type FileStore struct {
 	rootDir string
+	ctx     context.Context
 }
 
 func NewFileStore(rootDir string) (*FileStore, error) {
@@ -43,7 +38,7 @@ func NewFileStore(rootDir string) (*FileStore, error) {
 		return nil, fmt.Errorf("mlflow.NewFileStore: error getting absolute path: %w", err)
 	}
 	// Match the Python behavior of creating the default experiment.
-	fs := &FileStore{rootDir: rootDir}
+	fs := &FileStore{rootDir: rootDir, ctx: context.Background()}
 	if exp, _ := fs.GetExperiment(defaultExperimentID); exp == nil {
 		if _, err := fs.createExperiment(defaultName, defaultExperimentID); err != nil {
 			return nil, err
@@ -56,17 +51,36 @@ func (s *FileStore) URI() string {
 	return s.rootDir
 }
 
-func (f *FileStore) ExperimentsByName() (map[string]Experiment, error) {
-	files, err := os.ReadDir(f.rootDir)
+// Implements [Tracking.WithContext].
+// Cancelling ctx stops scans over experiments and runs, e.g. in SearchRuns.
+func (s *FileStore) WithContext(ctx context.Context) Tracking {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
+func (fs *FileStore) trashDir() string {
+	return filepath.Join(fs.rootDir, trashFolderName)
+}
+
+// readExperiments reads the experiments in dir, which is either the root or the trash directory.
+func (fs *FileStore) readExperiments(dir string) ([]*fileExperiment, error) {
+	files, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("mlflow.FileStore.ExperimentsByName: error reading directory: %w", err)
 	}
-	experiments := make(map[string]Experiment)
+	experiments := make([]*fileExperiment, 0, len(files))
 	for _, file := range files {
-		metaPath := filepath.Join(f.rootDir, file.Name(), metaDataFileName)
+		if err := fs.ctx.Err(); err != nil {
+			return nil, err
+		}
+		metaPath := filepath.Join(dir, file.Name(), metaDataFileName)
 		_, err := os.Stat(metaPath)
 		if err != nil && os.IsNotExist(err) {
 			// Ignore
@@ -77,12 +91,35 @@ func (f *FileStore) ExperimentsByName() (map[string]Experiment, error) {
 			return nil, fmt.Errorf("mlflow.FileStore.ExperimentsByName: error reading file: %w", err)
 		}
 		exp := &fileExperiment{
-			rootDir: filepath.Join(f.rootDir, file.Name()),
+			rootDir:  filepath.Join(dir, file.Name()),
+			storeDir: fs.rootDir,
+			ctx:      fs.ctx,
 		}
 		if err = yaml.Unmarshal(metaBytes, &exp.experimentMeta); err != nil {
 			return nil, err
 		}
-		experiments[exp.Name] = exp
+		experiments = append(experiments, exp)
+	}
+	return experiments, nil
+}
+
+func (fs *FileStore) ExperimentsByName(opts ...SearchOption) (map[string]Experiment, error) {
+	options := newSearchOptions(opts)
+	dirs := []string{fs.rootDir}
+	if options.viewType != ViewTypeActiveOnly {
+		dirs = append(dirs, fs.trashDir())
+	}
+	experiments := make(map[string]Experiment)
+	for _, dir := range dirs {
+		exps, err := fs.readExperiments(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, exp := range exps {
+			if options.viewType.includes(exp.LifecycleStage) {
+				experiments[exp.Name()] = exp
+			}
+		}
 	}
 	return experiments, nil
 }
@@ -92,11 +129,15 @@ func (fs *FileStore) GetOrCreateExperimentWithName(name string) (Experiment, err
 	if name == "" {
 		name = defaultName
 	}
-	expsByName, err := fs.ExperimentsByName()
+	// Include deleted experiments, since their names and IDs can't be reused.
+	expsByName, err := fs.ExperimentsByName(WithViewType(ViewTypeAll))
 	if err != nil {
 		return nil, err
 	}
 	if exp, ok := expsByName[name]; ok {
+		if exp.(*fileExperiment).LifecycleStage == LifecycleStageDeleted {
+			return nil, newError(protos.ErrorCode_RESOURCE_ALREADY_EXISTS, "experiment %q is deleted. Restore it or choose a different name", name)
+		}
 		return exp, nil
 	}
 	// Pick the ID here to avoid extra file I/O in createExperiment.
@@ -114,7 +155,7 @@ func (fs *FileStore) GetExperiment(id string) (Experiment, error) {
 	if id == "" {
 		id = defaultExperimentID
 	}
-	expsByName, err := fs.ExperimentsByName()
+	expsByName, err := fs.ExperimentsByName(WithViewType(ViewTypeAll))
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +164,26 @@ func (fs *FileStore) GetExperiment(id string) (Experiment, error) {
 			return exp, nil
 		}
 	}
-	return nil, fmt.Errorf("no experiment with id %s", id)
+	return nil, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no experiment with id %s", id)
+}
+
+// Implements [Tracking.GetRun].
+func (fs *FileStore) GetRun(runID string) (Run, error) {
+	if runID == "" {
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "runID is empty")
+	}
+	for _, dir := range []string{fs.rootDir, fs.trashDir()} {
+		exps, err := fs.readExperiments(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, exp := range exps {
+			if _, err := os.Stat(filepath.Join(exp.rootDir, runID, metaDataFileName)); err == nil {
+				return exp.GetRun(runID)
+			}
+		}
+	}
+	return nil, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no run with id %s", runID)
 }
 
 func ToURI(path string) string {
@@ -147,7 +207,12 @@ func (fs *FileStore) createExperiment(name, id string) (Experiment, error) {
 		if err != nil {
 			return nil, fmt.Errorf("mlflow.FileStore.createExperiment: error reading dir: %w", err)
 		}
-		for _, file := range files {
+		// IDs of deleted experiments can't be reused.
+		trashFiles, err := os.ReadDir(fs.trashDir())
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("mlflow.FileStore.createExperiment: error reading dir: %w", err)
+		}
+		for _, file := range append(files, trashFiles...) {
 			idInt, err := strconv.Atoi(file.Name())
 			if err == nil && idInt > highestID {
 				highestID = idInt
@@ -174,65 +239,32 @@ func (fs *FileStore) createExperiment(name, id string) (Experiment, error) {
 	exp := &fileExperiment{
 		experimentMeta: meta,
 		rootDir:        experimentPath,
+		storeDir:       fs.rootDir,
+		ctx:            fs.ctx,
 	}
 	exp.syncMeta()
 	return exp, nil
 }
 
-// Very limited filter support just for testing the one use case we have.
-func newRunFilter(filter string) (func(Run) bool, error) {
-	if filter == "" {
-		return func(Run) bool { return true }, nil
-	}
-	regexp := regexp.MustCompile(`tags\.` + "`?" + `(.+?)` + "`?" + `\s*=\s*'([^']*)'`)
-	matches := regexp.FindStringSubmatch(filter)
-	if len(matches) != 3 {
-		return nil, fmt.Errorf("mlflow.FileStore currently only supports filtering on a single tag. Got: %s", filter)
-	}
-	tagName := matches[1]
-	tagValue := matches[2]
-	return func(run Run) bool {
-		value, err := run.GetTag(tagName)
-		return err == nil && value == tagValue
-	}, nil
-}
-
-func (fs *FileStore) SearchRuns(experimentIDs []string, filter string, orderBy []string, pageToken string) ([]Run, string, error) {
-	filterFunc, err := newRunFilter(filter)
-	if err != nil {
-		return nil, "", err
-	}
-	experiments := make([]*fileExperiment, len(experimentIDs))
-	for i, id := range experimentIDs {
+func (fs *FileStore) SearchRuns(experimentIDs []string, filter string, orderBy []string, pageToken string, opts ...SearchOption) ([]Run, string, error) {
+	runs := make([]Run, 0)
+	for _, id := range experimentIDs {
 		if id == "" {
-			return nil, "", fmt.Errorf("SearchRuns: empty experiment ID is not valid")
+			return nil, "", newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "SearchRuns: empty experiment ID is not valid")
 		}
 		exp, err := fs.GetExperiment(id)
 		if err != nil {
 			return nil, "", err
 		}
-		experiments[i] = exp.(*fileExperiment)
-	}
-	runs := make([]Run, 0)
-	for _, exp := range experiments {
-		files, err := os.ReadDir(exp.rootDir)
+		expRuns, err := exp.(*fileExperiment).runs()
 		if err != nil {
-			return nil, "", fmt.Errorf("mlflow.FileStore.SearchRuns: error reading dir: %w", err)
+			return nil, "", err
 		}
-		for _, file := range files {
-			if !file.IsDir() {
-				continue
-			}
-			run, err := exp.GetRun(file.Name())
-			if err != nil {
-				return nil, "", err
-			}
-			if filterFunc(run) {
-				runs = append(runs, run)
-			}
+		for _, run := range expRuns {
+			runs = append(runs, run)
 		}
 	}
-	return runs, "", nil
+	return filterRuns(fs.ctx, runs, filter, orderBy, pageToken, opts...)
 }
 
 func (fs *FileStore) UIURL() string {
@@ -1,8 +1,11 @@
 package mlflow
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,7 +42,9 @@ func TestRun(t *testing.T) {
 	require.NoError(t, err)
 
 	_, err = exp.GetRun("run0")
-	require.Error(t, err)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = fs.GetExperiment("12345")
+	require.ErrorIs(t, err, ErrNotFound)
 
 	created, err := exp.CreateRun("run0")
 	require.NoError(t, err)
@@ -58,6 +63,10 @@ func TestRun(t *testing.T) {
 	gotTag, err := created.GetTag(tagKey)
 	require.NoError(t, err)
 	assert.Equal(t, gotTag, tagVal)
+	require.NoError(t, created.DeleteTag(tagKey))
+	_, err = created.GetTag(tagKey)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, created.DeleteTag(tagKey), ErrNotFound)
 
 	metrics := []Metric{
 		{Key: "metric0", Val: 0.0},
@@ -78,6 +87,257 @@ func TestRun(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, paramVal, "val1")
 
+	info, err := got.Info()
+	require.NoError(t, err)
+	assert.Equal(t, created.ID(), info.ID)
+	assert.Equal(t, "new name", info.Name)
+	assert.Equal(t, exp.ID(), info.ExperimentID)
+	assert.Equal(t, RunStatusRunning, info.Status)
+	assert.Equal(t, LifecycleStageActive, info.LifecycleStage)
+	assert.False(t, info.StartTime.IsZero())
+	assert.True(t, info.EndTime.IsZero())
+
 	assert.NoError(t, created.End())
+	info, err = got.Info()
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusFinished, info.Status)
+	assert.True(t, info.Status.IsTerminated())
+	assert.False(t, info.EndTime.Before(info.StartTime))
+
 	assert.NoError(t, created.Fail())
+	info, err = got.Info()
+	require.NoError(t, err)
+	assert.Equal(t, "FAILED", info.Status.String())
+}
+
+func TestMetricHistory(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+
+	history, pageToken, err := run.GetMetricHistory("metric0", "")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	assert.Empty(t, pageToken)
+
+	for step := int64(0); step < 3; step++ {
+		require.NoError(t, run.LogMetrics([]Metric{
+			{Key: "metric0", Val: float64(step)},
+			{Key: "metric1", Val: -float64(step)},
+		}, step))
+	}
+	history, pageToken, err = run.GetMetricHistory("metric0", "")
+	require.NoError(t, err)
+	assert.Empty(t, pageToken)
+	require.Len(t, history, 3)
+	for i, m := range history {
+		assert.Equal(t, "metric0", m.Key)
+		assert.Equal(t, float64(i), m.Val)
+		assert.Equal(t, int64(i), m.Step)
+		assert.False(t, m.Timestamp.IsZero())
+	}
+
+	latest, err := run.GetLatestMetrics()
+	require.NoError(t, err)
+	require.Len(t, latest, 2)
+	latestByKey := map[string]float64{}
+	for _, m := range latest {
+		latestByKey[m.Key] = m.Val
+	}
+	assert.Equal(t, map[string]float64{"metric0": 2, "metric1": -2}, latestByKey)
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+
+	require.NoError(t, run.Delete())
+	assert.Error(t, run.Delete())
+	assert.Error(t, run.LogMetric("metric0", 0, 0))
+	runs, _, err := fs.SearchRuns([]string{exp.ID()}, "", nil, "")
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+	runs, _, err = fs.SearchRuns([]string{exp.ID()}, "", nil, "", WithViewType(ViewTypeDeletedOnly))
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	info, err := runs[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, LifecycleStageDeleted, info.LifecycleStage)
+	require.NoError(t, run.Restore())
+	runs, _, err = fs.SearchRuns([]string{exp.ID()}, "", nil, "")
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+
+	require.NoError(t, exp.Delete())
+	assert.DirExists(t, filepath.Join(fs.rootDir, trashFolderName, exp.ID()))
+	expsByName, err := fs.ExperimentsByName()
+	require.NoError(t, err)
+	assert.NotContains(t, expsByName, "exp0")
+	expsByName, err = fs.ExperimentsByName(WithViewType(ViewTypeAll))
+	require.NoError(t, err)
+	assert.Contains(t, expsByName, "exp0")
+	_, err = fs.GetOrCreateExperimentWithName("exp0")
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	_, err = exp.CreateRun("run1")
+	assert.Error(t, err)
+	deleted, err := fs.GetExperiment(exp.ID())
+	require.NoError(t, err)
+	_, err = deleted.GetRun(run.ID())
+	require.NoError(t, err)
+
+	// A new experiment must not reuse the deleted experiment's ID.
+	exp1, err := fs.CreateExperiment("exp1")
+	require.NoError(t, err)
+	assert.NotEqual(t, exp.ID(), exp1.ID())
+
+	require.NoError(t, deleted.Restore())
+	expsByName, err = fs.ExperimentsByName()
+	require.NoError(t, err)
+	assert.Contains(t, expsByName, "exp0")
+	_, err = deleted.GetRun(run.ID())
+	require.NoError(t, err)
+}
+
+func TestExperimentMetadata(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	other, err := fs.GetOrCreateExperimentWithName("exp1")
+	require.NoError(t, err)
+	assert.Equal(t, "exp0", exp.Name())
+	_, err = exp.CreateRun("run0")
+	require.NoError(t, err)
+
+	require.NoError(t, exp.SetTag("owner", "alice"))
+	require.NoError(t, exp.SetTag("project", "x"))
+	val, err := exp.GetTag("owner")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", val)
+	require.NoError(t, exp.DeleteTag("project"))
+	_, err = exp.GetTag("project")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.Error(t, exp.SetName(other.Name()))
+	require.NoError(t, exp.SetName("renamed"))
+	expsByName, err := fs.ExperimentsByName()
+	require.NoError(t, err)
+	assert.Contains(t, expsByName, "renamed")
+	assert.NotContains(t, expsByName, "exp0")
+
+	info, err := expsByName["renamed"].Info()
+	require.NoError(t, err)
+	assert.Equal(t, exp.ID(), info.ID)
+	assert.Equal(t, "renamed", info.Name)
+	assert.Equal(t, LifecycleStageActive, info.LifecycleStage)
+	assert.Equal(t, []Tag{{Key: "owner", Val: "alice"}}, info.Tags)
+	assert.NotEmpty(t, info.ArtifactLocation)
+	assert.False(t, info.CreationTime.IsZero())
+	assert.False(t, info.LastUpdateTime.Before(info.CreationTime))
+
+	// The tags directory must not be mistaken for a run.
+	runs, _, err := fs.SearchRuns([]string{exp.ID()}, "", nil, "")
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
+
+func TestFileStoreWithContext(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	localPath := filepath.Join(t.TempDir(), "artifact.txt")
+	require.NoError(t, os.WriteFile(localPath, []byte("hello"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = fs.WithContext(ctx).ExperimentsByName()
+	assert.ErrorIs(t, err, context.Canceled)
+	_, _, err = fs.WithContext(ctx).SearchRuns([]string{exp.ID()}, "", nil, "")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, run.WithContext(ctx).LogArtifact(localPath, ""), context.Canceled)
+
+	// The original values are unaffected.
+	runs, _, err := fs.SearchRuns([]string{exp.ID()}, "", nil, "")
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+	assert.NoError(t, run.LogArtifact(localPath, ""))
+}
+
+func TestFileRunArtifacts(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	localDir := filepath.Join(t.TempDir(), "model")
+	require.NoError(t, os.MkdirAll(filepath.Join(localDir, "layers"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "config.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "layers", "0.bin"), []byte("weights"), 0644))
+	require.NoError(t, run.LogArtifact(localDir, "checkpoints"))
+
+	infos, err := run.ListArtifacts("checkpoints/model")
+	require.NoError(t, err)
+	assert.Equal(t, []ArtifactInfo{
+		{Path: "checkpoints/model/config.json", Size: 2},
+		{Path: "checkpoints/model/layers", IsDir: true},
+	}, infos)
+	infos, err = run.ListArtifacts("checkpoints/model/config.json")
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+	infos, err = run.ListArtifacts("missing")
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+
+	r, err := run.OpenArtifact("checkpoints/model/layers/0.bin")
+	require.NoError(t, err)
+	contents, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, []byte("weights"), contents)
+	_, err = run.OpenArtifact("missing.bin")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = run.OpenArtifact("checkpoints")
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+
+	dstDir := t.TempDir()
+	localPath, err := run.DownloadArtifacts("checkpoints/model/layers/0.bin", dstDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dstDir, "checkpoints", "model", "layers", "0.bin"), localPath)
+	contents, err = os.ReadFile(localPath)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("weights"), contents)
+
+	dstDir = t.TempDir()
+	localPath, err = run.DownloadArtifacts("", dstDir)
+	require.NoError(t, err)
+	assert.Equal(t, dstDir, localPath)
+	contents, err = os.ReadFile(filepath.Join(dstDir, "checkpoints", "model", "config.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("{}"), contents)
+	assert.FileExists(t, filepath.Join(dstDir, "checkpoints", "model", "layers", "0.bin"))
+	entries, err := os.ReadDir(filepath.Join(dstDir, "checkpoints", "model"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary files are left behind")
+
+	_, err = run.DownloadArtifacts("missing", t.TempDir())
+	assert.ErrorIs(t, err, ErrNotFound)
+	require.NoError(t, os.MkdirAll(filepath.Join(run.(*fileRun).ArtifactDir(), "checkpoints", "empty"), 0755))
+	dstDir = t.TempDir()
+	localPath, err = run.DownloadArtifacts("checkpoints/empty", dstDir)
+	require.NoError(t, err)
+	assert.DirExists(t, localPath)
+	// Paths can't refer to files outside of the run's artifacts.
+	_, err = run.OpenArtifact("../meta.yaml")
+	assert.ErrorIs(t, err, ErrNotFound)
 }
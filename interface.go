@@ -3,27 +3,37 @@
 // It supports the Tracking API, with local files and HTTP.
 // The API is modeled after the official Python client, so the [official MLFlow docs] may be useful.
 //
-// Authentication to Databricks-hosted MLFlow is only supported via access token, not via Databricks username and password.
-// Follow the [personal acess token instructions] to get one.
+// To use Databricks-hosted MLFlow, set the tracking URI to "databricks" or "databricks://<profile>"
+// to use the host and credentials from the Databricks config file (~/.databrickscfg), like the Python client.
+// Credentials can be a [personal access token], or a service principal's OAuth client ID and secret
+// for [OAuth machine-to-machine] auth.
 //
 // The API is organized into a hierarchy of interfaces:
 // - [Tracking]: represents an MLFlow tracking server.
 // - [Experiment]: represents an MLFlow experiment.
 // - [Run]: represents an MLFlow run.
 //
+// Each of these has a WithContext method that returns a copy bound to a [context.Context],
+// so that operations can be cancelled or given a deadline. Experiments and runs obtained
+// from a copy inherit its context.
+//
 // [official MLFlow docs]: https://mlflow.org/docs/latest/tracking.html
-// [personal acess token instructions]: https://docs.databricks.com/dev-tools/api/latest/authentication.html#generate-a-personal-access-token
+// [personal access token]: https://docs.databricks.com/dev-tools/api/latest/authentication.html#generate-a-personal-access-token
+// [OAuth machine-to-machine]: https://docs.databricks.com/en/dev-tools/auth/oauth-m2m.html
 package mlflow
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/url"
 	"os"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -31,6 +41,20 @@ const (
 	ExperimentIDEnvName = "MLFLOW_EXPERIMENT_ID"
 	RunIDEnvName        = "MLFLOW_RUN_ID"
 	BearerTokenEnvName  = "MLFLOW_TRACKING_TOKEN"
+	// See [WithBasicAuth].
+	UsernameEnvName = "MLFLOW_TRACKING_USERNAME"
+	PasswordEnvName = "MLFLOW_TRACKING_PASSWORD"
+	// See [WithMaxRetries] and [WithBackoffFactor]. The backoff factor is in seconds.
+	MaxRetriesEnvName    = "MLFLOW_HTTP_REQUEST_MAX_RETRIES"
+	BackoffFactorEnvName = "MLFLOW_HTTP_REQUEST_BACKOFF_FACTOR"
+	// See [WithTimeout]. In seconds.
+	TimeoutEnvName = "MLFLOW_HTTP_REQUEST_TIMEOUT"
+	// See [WithTLSConfig]. If "true", the server's certificate is not verified.
+	InsecureTLSEnvName = "MLFLOW_TRACKING_INSECURE_TLS"
+	// Path to a PEM file of CA certificates used to verify the server instead of the system's.
+	ServerCertPathEnvName = "MLFLOW_TRACKING_SERVER_CERT_PATH"
+	// Path to a PEM file containing a client certificate and its private key.
+	ClientCertPathEnvName = "MLFLOW_TRACKING_CLIENT_CERT_PATH"
 
 	// https://www.mlflow.org/docs/latest/tracking.html#system-tags
 	GitCommitTagKey   = "mlflow.source.git.commit"
@@ -48,6 +72,11 @@ const (
 	defaultExperimentID = "0"
 	// https://github.com/mlflow/mlflow/blob/da4fe0f1509ff5062016b2efc05e73876db118c2/mlflow/entities/experiment.py#L14
 	defaultName = "Default"
+	// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/utils/search_utils.py#L47
+	defaultSearchRunsMaxResults = 1000
+	maxSearchRunsMaxResults     = 50000
+	// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/store/tracking/__init__.py#L13
+	metricHistoryMaxResults = 25000
 )
 
 var (
@@ -58,20 +87,156 @@ var (
 // It is generally used indirectly via [ActiveRunFromEnv], or you can create one with
 // [NewTracking].
 type Tracking interface {
-	ExperimentsByName() (map[string]Experiment, error)
+	// Returns active experiments by default. Use [WithViewType] to include deleted experiments.
+	ExperimentsByName(opts ...SearchOption) (map[string]Experiment, error)
 	CreateExperiment(name string) (Experiment, error)
 	GetOrCreateExperimentWithName(name string) (Experiment, error)
+	// Returns the experiment even if it has been deleted.
 	GetExperiment(id string) (Experiment, error)
+	// GetRun returns the run with the given ID, in whichever experiment it is.
+	// Returns the run even if it or its experiment has been deleted.
+	GetRun(runID string) (Run, error)
 	URI() string
 	UIURL() string
+	// Returns active runs by default. Use [WithViewType] to include deleted runs.
 	// Returns (matching runs, next page token, error)
-	SearchRuns(experimentIDs []string, filter string, orderBy []string, pageToken string) ([]Run, string, error)
+	SearchRuns(experimentIDs []string, filter string, orderBy []string, pageToken string, opts ...SearchOption) ([]Run, string, error)
+	// WithContext returns a copy that uses ctx for all operations, including those on
+	// experiments and runs obtained from the copy. ctx must not be nil.
+	WithContext(ctx context.Context) Tracking
+}
+
+// ViewType selects whether active entities, deleted entities, or both are returned.
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/protos/service.proto#L428
+type ViewType int
+
+const (
+	ViewTypeActiveOnly  ViewType = 1
+	ViewTypeDeletedOnly ViewType = 2
+	ViewTypeAll         ViewType = 3
+)
+
+// includes returns true if entities with the given lifecycle stage should be returned.
+func (v ViewType) includes(lifecycleStage string) bool {
+	switch v {
+	case ViewTypeDeletedOnly:
+		return lifecycleStage == LifecycleStageDeleted
+	case ViewTypeAll:
+		return true
+	}
+	return lifecycleStage != LifecycleStageDeleted
+}
+
+// SearchOption configures [Tracking.SearchRuns] and [Tracking.ExperimentsByName].
+type SearchOption func(*searchOptions)
+
+type searchOptions struct {
+	maxResults int
+	viewType   ViewType
+}
+
+func newSearchOptions(opts []SearchOption) searchOptions {
+	options := searchOptions{maxResults: defaultSearchRunsMaxResults, viewType: ViewTypeActiveOnly}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// SearchOptionValues returns the values set by opts, with defaults for those that are not set.
+// It is for implementations of [Tracking] outside of this package.
+func SearchOptionValues(opts ...SearchOption) (maxResults int, viewType ViewType) {
+	options := newSearchOptions(opts)
+	return options.maxResults, options.viewType
+}
+
+// WithMaxResults sets the maximum number of runs returned by a single call to [Tracking.SearchRuns].
+// The rest can be fetched using the returned page token. Defaults to 1000.
+func WithMaxResults(n int) SearchOption {
+	return func(o *searchOptions) {
+		o.maxResults = n
+	}
+}
+
+// WithViewType sets whether active runs or experiments, deleted ones, or both are returned.
+// Defaults to [ViewTypeActiveOnly].
+func WithViewType(viewType ViewType) SearchOption {
+	return func(o *searchOptions) {
+		o.viewType = viewType
+	}
+}
+
+// ExperimentInfo is the metadata of an experiment.
+type ExperimentInfo struct {
+	ID               string
+	Name             string
+	ArtifactLocation string
+	// One of [LifecycleStageActive] or [LifecycleStageDeleted].
+	LifecycleStage string
+	CreationTime   time.Time
+	LastUpdateTime time.Time
+	Tags           []Tag
+}
+
+// CreateRunOption configures [Experiment.CreateRun].
+type CreateRunOption func(*createRunOptions)
+
+type createRunOptions struct {
+	startTime time.Time
+	tags      []Tag
+}
+
+func newCreateRunOptions(opts []CreateRunOption) createRunOptions {
+	options := createRunOptions{startTime: time.Now()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// CreateRunOptionValues returns the values set by opts. startTime is zero if it is not set.
+// It is for implementations of [Experiment] outside of this package.
+func CreateRunOptionValues(opts ...CreateRunOption) (startTime time.Time, tags []Tag) {
+	var options createRunOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options.startTime, options.tags
+}
+
+// WithStartTime sets the start time of the run. Defaults to the current time.
+func WithStartTime(startTime time.Time) CreateRunOption {
+	return func(o *createRunOptions) {
+		o.startTime = startTime
+	}
+}
+
+// WithTags sets tags on the run when it is created. Some tags, such as [UserTagKey],
+// can only be set this way on some tracking servers.
+func WithTags(tags []Tag) CreateRunOption {
+	return func(o *createRunOptions) {
+		o.tags = append(o.tags, tags...)
+	}
 }
 
 type Experiment interface {
-	CreateRun(name string) (Run, error)
+	CreateRun(name string, opts ...CreateRunOption) (Run, error)
 	GetRun(runId string) (Run, error)
 	ID() string
+	Name() string
+	// SetName renames the experiment. The new name must not be used by any other experiment.
+	SetName(name string) error
+	SetTag(key, value string) error
+	GetTag(key string) (string, error)
+	DeleteTag(key string) error
+	// Info fetches the current metadata of the experiment from the tracking server.
+	Info() (ExperimentInfo, error)
+	// Delete marks the experiment and its runs as deleted. It can be undone with Restore.
+	Delete() error
+	Restore() error
+	// WithContext returns a copy that uses ctx for all operations, including those on
+	// runs obtained from the copy. ctx must not be nil.
+	WithContext(ctx context.Context) Experiment
 }
 
 type Metric struct {
@@ -79,6 +244,14 @@ type Metric struct {
 	Val float64
 }
 
+// LoggedMetric is a single value of a metric as recorded by the tracking server.
+type LoggedMetric struct {
+	Key       string
+	Val       float64
+	Step      int64
+	Timestamp time.Time
+}
+
 type Param struct {
 	Key string
 	Val string
@@ -89,60 +262,184 @@ type Tag struct {
 	Val string
 }
 
+// RunStatus is the status of a run.
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/protos/service.proto#L439
+type RunStatus int
+
+const (
+	RunStatusRunning   RunStatus = 1
+	RunStatusScheduled RunStatus = 2
+	RunStatusFinished  RunStatus = 3
+	RunStatusFailed    RunStatus = 4
+	RunStatusKilled    RunStatus = 5
+)
+
+func (s RunStatus) String() string {
+	switch s {
+	case RunStatusRunning:
+		return "RUNNING"
+	case RunStatusScheduled:
+		return "SCHEDULED"
+	case RunStatusFinished:
+		return "FINISHED"
+	case RunStatusFailed:
+		return "FAILED"
+	case RunStatusKilled:
+		return "KILLED"
+	}
+	return fmt.Sprintf("RunStatus(%d)", int(s))
+}
+
+// IsTerminated returns true if the run has finished, failed or been killed.
+func (s RunStatus) IsTerminated() bool {
+	return s == RunStatusFinished || s == RunStatusFailed || s == RunStatusKilled
+}
+
+// RunInfo is the metadata of a run.
+type RunInfo struct {
+	ID           string
+	Name         string
+	ExperimentID string
+	UserID       string
+	Status       RunStatus
+	StartTime    time.Time
+	// Zero if the run has not ended.
+	EndTime     time.Time
+	ArtifactURI string
+	// One of [LifecycleStageActive] or [LifecycleStageDeleted].
+	LifecycleStage string
+}
+
 type Run interface {
 	SetName(name string) error
 	Name() string
 	SetTag(key, value string) error
 	SetTags(tags []Tag) error
+	// GetTag returns an error matching [ErrNotFound] if the tag is not set.
 	GetTag(key string) (string, error)
+	DeleteTag(key string) error
 	LogArtifact(localPath, artifactPath string) error
+	// ListArtifacts, DownloadArtifacts and OpenArtifact read the run's artifacts,
+	// see the methods of the same name on [ArtifactRepo].
+	ListArtifacts(artifactPath string) ([]ArtifactInfo, error)
+	DownloadArtifacts(artifactPath, dstDir string) (string, error)
+	OpenArtifact(artifactPath string) (io.ReadCloser, error)
 	LogMetric(key string, val float64, step int64) error
 	LogMetrics(metrics []Metric, step int64) error
+	// LogBatch logs metrics, params and tags in as few requests as possible.
+	// Metrics with a zero Timestamp are logged with the current time.
+	LogBatch(metrics []LoggedMetric, params []Param, tags []Tag) error
+	// GetMetricHistory returns the values logged for the metric with the given key,
+	// in the order they were logged. Long histories are split into pages.
+	// Returns (metric values, next page token, error)
+	GetMetricHistory(key, pageToken string) ([]LoggedMetric, string, error)
+	// GetLatestMetrics returns the latest value of each metric logged to the run.
+	GetLatestMetrics() ([]LoggedMetric, error)
 	LogParam(key, value string) error
 	LogParams(params []Param) error
 	GetParam(key string) (string, error)
 	End() error
 	Fail() error
+	// SetTerminated sets the status and end time of the run, e.g. to copy a run that ended earlier.
+	// End and Fail are shorthands for this with the current time.
+	SetTerminated(status RunStatus, endTime time.Time) error
+	// Params fetches all of the run's params, sorted by key.
+	Params() ([]Param, error)
+	// Tags fetches all of the run's tags, sorted by key.
+	Tags() ([]Tag, error)
+	// Delete marks the run as deleted. It can be undone with Restore.
+	Delete() error
+	Restore() error
+	// Info fetches the current metadata of the run from the tracking server,
+	// so it reflects changes made by other clients.
+	Info() (RunInfo, error)
 	UIURL() string
 	ID() string
 	ExperimentID() string
+	// WithContext returns a copy that uses ctx for all operations, including artifact uploads.
+	// ctx must not be nil.
+	WithContext(ctx context.Context) Run
 }
 
-// ArtifactRepo is an interface for logging artifacts.
+// ArtifactInfo describes a file or directory in an [ArtifactRepo].
+type ArtifactInfo struct {
+	// Slash-separated and relative to the root of the repo, e.g. "checkpoints/model.bin".
+	Path  string
+	IsDir bool
+	// Size in bytes. Zero for directories.
+	Size int64
+}
+
+// ArtifactRepo is an interface for logging and reading artifacts.
 // It is generally used indirectly via [Run.LogArtifact].
+// Artifact paths are slash-separated and relative to the root of the repo. "" is the root.
 type ArtifactRepo interface {
 	// localPath is the path to the file on the local filesystem.
-	// artifactPath is the directory in the artifact repo to upload the file to.
+	// artifactPath is the directory in the artifact repo to upload the file to,
+	// e.g. LogArtifact("/tmp/model.bin", "checkpoints") logs checkpoints/model.bin.
 	// Kinda weird, but this is how the python client does it.
 	LogArtifact(localPath, artifactPath string) error
 	// LogArtifacts logs all of the files in a directory tree as artifacts.
 	// localPath is the path to the directory on the local filesystem.
-	// artifactPath is the directory in the artifact repo to upload to.
+	// artifactPath is the directory in the artifact repo to upload its contents to,
+	// e.g. LogArtifacts("/tmp/model", "checkpoints") logs /tmp/model/layers/0.bin as checkpoints/layers/0.bin.
 	LogArtifacts(localDir, artifactPath string) error
+	// ListArtifacts returns the files and directories directly in the directory at artifactPath,
+	// sorted by path. Returns an empty list if there is no such directory.
+	ListArtifacts(artifactPath string) ([]ArtifactInfo, error)
+	// DownloadArtifacts downloads the file or directory tree at artifactPath to dstDir, keeping its path
+	// relative to the root, e.g. "checkpoints/model.bin" is downloaded to dstDir/checkpoints/model.bin.
+	// Existing files are overwritten. Returns the local path of the artifact,
+	// or an error matching [ErrNotFound] if there is nothing at artifactPath.
+	DownloadArtifacts(artifactPath, dstDir string) (string, error)
+	// OpenArtifact opens the file at artifactPath for reading. The caller must close it.
+	// Returns an error matching [ErrNotFound] if there is no such file.
+	OpenArtifact(artifactPath string) (io.ReadCloser, error)
+	// WithContext returns a copy that uses ctx for all uploads and downloads. ctx must not be nil.
+	WithContext(ctx context.Context) ArtifactRepo
 }
 
+// NewTracking creates a client for the tracking server at uri.
+// Settings that are not passed explicitly are read from environment variables, e.g. [TrackingURIEnvName].
+// Credentials can also be set with [UsernameEnvName] and [PasswordEnvName].
 func NewTracking(uri, bearerToken string, l *log.Logger) (Tracking, error) {
+	return newTracking(uri, bearerToken, l, os.Getenv)
+}
+
+func newTracking(uri, bearerToken string, l *log.Logger, getConfig func(string) string) (Tracking, error) {
 	if uri == "" {
-		uri = os.Getenv(TrackingURIEnvName)
+		uri = getConfig(TrackingURIEnvName)
 	}
 	if uri == "" {
 		return nil, fmt.Errorf("uri not specified and no %q found, but it's required", TrackingURIEnvName)
 	}
+	if bearerToken == "" {
+		bearerToken = getConfig(BearerTokenEnvName)
+	}
+	if uri == "databricks" {
+		return newDatabricksTracking("", bearerToken, getConfig)
+	}
 	parsed, err := url.Parse(uri)
 	if err != nil {
 		return nil, err
 	}
-	if bearerToken == "" {
-		bearerToken = os.Getenv(BearerTokenEnvName)
-	}
 	switch parsed.Scheme {
 	case "file", "":
 		if bearerToken != "" && l != nil {
 			l.Println("Bearer token ignored for local file tracking URI")
 		}
+		if getConfig(UsernameEnvName) != "" && l != nil {
+			l.Println("Username and password ignored for local file tracking URI")
+		}
 		return NewFileStore(parsed.Path)
 	case "http", "https":
-		return NewRESTStore(uri, bearerToken)
+		opts, err := restStoreOptionsFromConfig(getConfig)
+		if err != nil {
+			return nil, err
+		}
+		return NewRESTStore(uri, bearerToken, opts...)
+	case "databricks":
+		return newDatabricksTracking(parsed.Host, bearerToken, getConfig)
 	}
 	return nil, fmt.Errorf("support for tracking service with URI scheme %s not implemented", parsed.Scheme)
 }
@@ -178,7 +475,7 @@ func getActiveRun(experimentName string, l *log.Logger, getConfig func(string) s
 	if activeRun != nil && l != nil && experimentName != "" {
 		l.Println("Active run already exists, ignoring experiment name")
 	} else {
-		tracking, err := NewTracking("", getConfig(BearerTokenEnvName), l)
+		tracking, err := newTracking("", "", l, getConfig)
 		if err != nil {
 			return nil, err
 		}
@@ -234,12 +531,43 @@ func getActiveRun(experimentName string, l *log.Logger, getConfig func(string) s
 
 func endIfActive(run Run) {
 	activeRunMtx.Lock()
-	if activeRun == run {
+	// Compare IDs rather than values, since copies made by WithContext are distinct values.
+	if activeRun != nil && activeRun.ID() == run.ID() {
 		activeRun = nil
 	}
 	activeRunMtx.Unlock()
 }
 
+// newDatabricksTracking creates a client for the Databricks workspace in the given profile
+// of the Databricks config file. See [readDatabricksConfig] for how the profile is chosen.
+func newDatabricksTracking(profile, bearerToken string, getConfig func(string) string) (Tracking, error) {
+	config, err := readDatabricksConfig(profile, getConfig)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := restStoreOptionsFromConfig(getConfig)
+	if err != nil {
+		return nil, err
+	}
+	if bearerToken == "" {
+		bearerToken = config.token
+		authOpts, err := databricksRESTStoreOptions(config)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, authOpts...)
+	}
+	return NewRESTStore(config.host, bearerToken, opts...)
+}
+
+// timeFromMillis converts a Unix timestamp in milliseconds, where 0 means unset, to a time.Time.
+func timeFromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
 func stringFieldFromStruct(key string, config interface{}) string {
 	val := reflect.ValueOf(config)
 	if val.Kind() == reflect.Ptr {
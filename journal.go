@@ -0,0 +1,589 @@
+package mlflow
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+const (
+	journalFileExt = ".journal"
+	// Appended to the path of a journal for the operations in it that the server rejected.
+	rejectedJournalExt = ".rejected"
+)
+
+// JournaledRun wraps a [Run] so that logging doesn't fail while the tracking server is unreachable.
+// Metrics, params, tags and the final status that can't be sent are appended to a journal file
+// on disk, and replayed in order once the server is reachable again. While there are unsent
+// operations, new ones are also journaled, so that the order is preserved.
+//
+// A replay is attempted before each operation, at most once per replay interval, and by
+// [JournaledRun.Replay]. Journals left behind by processes that exited before they could be
+// replayed can be replayed with [ReplayJournals].
+// Operations that the server rejects when they are replayed are moved to a file next to the journal,
+// with ".rejected" appended to its name, and reported by the replay that rejected them.
+//
+// Methods other than the logging methods, End, Fail and SetTerminated are passed through to the wrapped run.
+type JournaledRun struct {
+	Run
+	path           string
+	replayInterval time.Duration
+
+	mtx sync.Mutex
+	// True if the journal has operations that have not been replayed.
+	offline    bool
+	lastReplay time.Time
+}
+
+// journalHeader is the first line of a journal file. It identifies the run.
+type journalHeader struct {
+	ExperimentID string `json:"experiment_id"`
+	RunID        string `json:"run_id"`
+}
+
+// journalEntry is a line of a journal file after the header.
+type journalEntry struct {
+	// One of the journalOp constants.
+	Op      string         `json:"op"`
+	Metrics []LoggedMetric `json:"metrics,omitempty"`
+	Params  []Param        `json:"params,omitempty"`
+	Tags    []Tag          `json:"tags,omitempty"`
+	// For journalOpDeleteTag.
+	Key string `json:"key,omitempty"`
+	// For journalOpTerminate. EndTime is a Unix timestamp in milliseconds.
+	Status  RunStatus `json:"status,omitempty"`
+	EndTime int64     `json:"end_time,omitempty"`
+}
+
+// journalMetric is how a metric is written in a journal, so that NaN and infinite values can be journaled.
+// It has the same fields as LoggedMetric.
+type journalMetric struct {
+	Key       string
+	Val       jsonFloat64
+	Step      int64
+	Timestamp time.Time
+}
+
+func (e journalEntry) MarshalJSON() ([]byte, error) {
+	type plainEntry journalEntry
+	var metrics []journalMetric
+	for _, m := range e.Metrics {
+		metrics = append(metrics, journalMetric{Key: m.Key, Val: jsonFloat64(m.Val), Step: m.Step, Timestamp: m.Timestamp})
+	}
+	return json.Marshal(struct {
+		plainEntry
+		Metrics []journalMetric `json:"metrics,omitempty"`
+	}{plainEntry(e), metrics})
+}
+
+func (e *journalEntry) UnmarshalJSON(data []byte) error {
+	type plainEntry journalEntry
+	var v struct {
+		plainEntry
+		Metrics []journalMetric `json:"metrics"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*e = journalEntry(v.plainEntry)
+	e.Metrics = nil
+	for _, m := range v.Metrics {
+		e.Metrics = append(e.Metrics, LoggedMetric{Key: m.Key, Val: float64(m.Val), Step: m.Step, Timestamp: m.Timestamp})
+	}
+	return nil
+}
+
+const (
+	journalOpBatch     = "batch"
+	journalOpDeleteTag = "delete_tag"
+	journalOpTerminate = "terminate"
+)
+
+// NewJournaledRun wraps run, using a journal file for it in dir. The directory is created if needed.
+// If there is already a journal for the run in dir, e.g. because the process restarted,
+// new operations are appended to it.
+func NewJournaledRun(run Run, dir string) (*JournaledRun, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("mlflow.NewJournaledRun: error creating journal dir: %w", err)
+	}
+	j := &JournaledRun{
+		Run:            run,
+		path:           filepath.Join(dir, run.ID()+journalFileExt),
+		replayInterval: 30 * time.Second,
+	}
+	if _, err := os.Stat(j.path); err == nil {
+		j.offline = true
+	}
+	return j, nil
+}
+
+// isUnavailable returns true if err means the tracking server could not be reached,
+// as opposed to it rejecting the request or the caller giving up.
+func isUnavailable(err error) bool {
+	var mlflowErr *Error
+	if errors.As(err, &mlflowErr) {
+		return mlflowErr.StatusCode >= http.StatusInternalServerError ||
+			mlflowErr.StatusCode == http.StatusTooManyRequests ||
+			mlflowErr.StatusCode == http.StatusRequestTimeout
+	}
+	// RESTStore returns the error of the caller's context as is, e.g. if it was cancelled,
+	// rather than as a *url.Error like HTTP client timeouts, which also match context.DeadlineExceeded.
+	// Other request errors, e.g. TLS certificate errors, won't be fixed by retrying.
+	var urlErr *url.Error
+	if errors.Is(err, context.Canceled) || !errors.As(err, &urlErr) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || urlErr.Timeout()
+}
+
+// isRejected returns true if err means the server won't accept a request however often it is sent,
+// e.g. because it is invalid. Authentication errors aren't included, since they may be fixed by the user.
+func isRejected(err error) bool {
+	var mlflowErr *Error
+	if !errors.As(err, &mlflowErr) || isUnavailable(err) {
+		return false
+	}
+	return mlflowErr.Code != protos.ErrorCode_UNAUTHENTICATED.String() &&
+		mlflowErr.Code != protos.ErrorCode_PERMISSION_DENIED.String()
+}
+
+// do sends entry with send, or journals it if the server is unavailable.
+func (j *JournaledRun) do(entry journalEntry, send func() error) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	var replayErr error
+	if j.offline && time.Since(j.lastReplay) >= j.replayInterval {
+		if err := j.replayLocked(); err != nil && !isUnavailable(err) {
+			// Send or journal the entry anyway, so that it isn't lost.
+			replayErr = err
+		}
+	}
+	if !j.offline {
+		err := send()
+		if err == nil {
+			return replayErr
+		}
+		if !isUnavailable(err) {
+			return err
+		}
+		j.offline = true
+		j.lastReplay = time.Now()
+	}
+	header := journalHeader{ExperimentID: j.Run.ExperimentID(), RunID: j.Run.ID()}
+	if err := appendJournal(j.path, header, entry); err != nil {
+		return err
+	}
+	return replayErr
+}
+
+// Replay sends all journaled operations to the tracking server.
+// Returns nil if there are none.
+func (j *JournaledRun) Replay() error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	return j.replayLocked()
+}
+
+func (j *JournaledRun) replayLocked() error {
+	if !j.offline {
+		return nil
+	}
+	j.lastReplay = time.Now()
+	err := replayJournal(j.Run, j.path)
+	// The journal is removed once everything in it was sent or rejected.
+	if _, statErr := os.Stat(j.path); os.IsNotExist(statErr) {
+		j.offline = false
+	}
+	return err
+}
+
+// Implements [Run.LogBatch].
+func (j *JournaledRun) LogBatch(metrics []LoggedMetric, params []Param, tags []Tag) error {
+	// Set timestamps now so that they are the same whether sent now or replayed later.
+	now := time.Now()
+	logged := make([]LoggedMetric, len(metrics))
+	for i, m := range metrics {
+		if m.Timestamp.IsZero() {
+			m.Timestamp = now
+		}
+		logged[i] = m
+	}
+	return j.do(journalEntry{Op: journalOpBatch, Metrics: logged, Params: params, Tags: tags}, func() error {
+		return j.Run.LogBatch(logged, params, tags)
+	})
+}
+
+func (j *JournaledRun) LogMetric(key string, val float64, step int64) error {
+	return j.LogBatch([]LoggedMetric{{Key: key, Val: val, Step: step}}, nil, nil)
+}
+
+func (j *JournaledRun) LogMetrics(metrics []Metric, step int64) error {
+	logged := make([]LoggedMetric, len(metrics))
+	for i, m := range metrics {
+		logged[i] = LoggedMetric{Key: m.Key, Val: m.Val, Step: step}
+	}
+	return j.LogBatch(logged, nil, nil)
+}
+
+func (j *JournaledRun) LogParam(key, value string) error {
+	return j.LogBatch(nil, []Param{{Key: key, Val: value}}, nil)
+}
+
+func (j *JournaledRun) LogParams(params []Param) error {
+	return j.LogBatch(nil, params, nil)
+}
+
+func (j *JournaledRun) SetTag(key, value string) error {
+	return j.LogBatch(nil, nil, []Tag{{Key: key, Val: value}})
+}
+
+func (j *JournaledRun) SetTags(tags []Tag) error {
+	return j.LogBatch(nil, nil, tags)
+}
+
+// Implements [Run.DeleteTag].
+func (j *JournaledRun) DeleteTag(key string) error {
+	return j.do(journalEntry{Op: journalOpDeleteTag, Key: key}, func() error {
+		return j.Run.DeleteTag(key)
+	})
+}
+
+// End ends the run, or journals that it ended if the server is unavailable.
+func (j *JournaledRun) End() error {
+	return j.SetTerminated(RunStatusFinished, time.Now())
+}
+
+// Fail marks the run as failed, or journals that it failed if the server is unavailable.
+func (j *JournaledRun) Fail() error {
+	return j.SetTerminated(RunStatusFailed, time.Now())
+}
+
+// Implements [Run.SetTerminated].
+func (j *JournaledRun) SetTerminated(status RunStatus, endTime time.Time) error {
+	err := j.do(journalEntry{Op: journalOpTerminate, Status: status, EndTime: endTime.UnixMilli()}, func() error {
+		return j.Run.SetTerminated(status, endTime)
+	})
+	if err == nil {
+		endIfActive(j)
+	}
+	return err
+}
+
+func appendJournal(path string, header journalHeader, entry journalEntry) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("mlflow.JournaledRun: error opening journal: %w", err)
+	}
+	defer f.Close()
+	size, err := truncatePartialLine(f)
+	if err != nil {
+		return fmt.Errorf("mlflow.JournaledRun: error repairing journal: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	if size == 0 {
+		if err := enc.Encode(header); err != nil {
+			return err
+		}
+	}
+	if err := enc.Encode(entry); err != nil {
+		return fmt.Errorf("mlflow.JournaledRun: error writing journal: %w", err)
+	}
+	// Make sure the entry survives a crash.
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("mlflow.JournaledRun: error writing journal: %w", err)
+	}
+	return f.Close()
+}
+
+// truncatePartialLine removes an incomplete last line, which is left if the process crashed
+// while writing it, so that the next entry doesn't get appended to it. Returns the new size of f.
+func truncatePartialLine(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	buf := make([]byte, 4096)
+	end := size
+	for end > 0 {
+		start := end - int64(len(buf))
+		if start < 0 {
+			start = 0
+		}
+		chunk := buf[:end-start]
+		if _, err := f.ReadAt(chunk, start); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+	if end == size {
+		return size, nil
+	}
+	return end, f.Truncate(end)
+}
+
+func readJournal(path string) (journalHeader, []journalEntry, error) {
+	var header journalHeader
+	f, err := os.Open(path)
+	if err != nil {
+		return header, nil, err
+	}
+	defer f.Close()
+	lines := make([][]byte, 0)
+	scanner := bufio.NewScanner(f)
+	// Batches can be large.
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	if err := scanner.Err(); err != nil {
+		return header, nil, err
+	}
+	entries := make([]journalEntry, 0, len(lines))
+	for i, line := range lines {
+		var err error
+		if i == 0 {
+			err = json.Unmarshal(line, &header)
+		} else {
+			var entry journalEntry
+			if err = json.Unmarshal(line, &entry); err == nil {
+				entries = append(entries, entry)
+			}
+		}
+		// The last line may be incomplete if the process crashed while writing it.
+		if err != nil && (i == 0 || i < len(lines)-1) {
+			return header, nil, fmt.Errorf("%s:%d: invalid journal entry: %w", path, i+1, err)
+		}
+	}
+	return header, entries, nil
+}
+
+// writeJournal atomically replaces the journal at path with header and entries,
+// or removes it if there are no entries.
+func writeJournal(path string, header journalHeader, entries []journalEntry) error {
+	if len(entries) == 0 {
+		return os.Remove(path)
+	}
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	if err = enc.Encode(header); err == nil {
+		for _, entry := range entries {
+			if err = enc.Encode(entry); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// replayJournal sends the operations in the journal at path to run, then removes the journal.
+// Consecutive batches are merged and deduplicated, so that they are sent in as few requests as possible.
+// After each request, the journal is rewritten without the operations that were sent, so that
+// if replay fails it can be resumed later. If the process crashed right after a request, the first
+// operation may have been sent already. Sending params, tags and the status again is harmless,
+// but metrics would be logged twice, so the ones that are already in the run's history are skipped.
+//
+// Operations that the server rejects, e.g. because a param was changed, would stop the replay every time,
+// so they are moved to a file at path with rejectedJournalExt appended, and the rest are still replayed.
+// The rejections are reported in the returned error, only by the replay that moved them.
+func replayJournal(run Run, path string) error {
+	header, entries, err := readJournal(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var rejected int
+	var rejectedErr error
+	// The number of batches to send one at a time, because the merged batch with them was rejected.
+	unmerged := 0
+	for first := true; len(entries) > 0; first = false {
+		n := 1
+		switch entries[0].Op {
+		case journalOpBatch:
+			for unmerged == 0 && n < len(entries) && entries[n].Op == journalOpBatch {
+				n++
+			}
+			metrics, params, tags := mergeBatches(entries[:n])
+			if first || unmerged > 0 {
+				// A rejected merged batch may have been partially logged.
+				if metrics, err = unloggedMetrics(run, metrics); err != nil {
+					return fmt.Errorf("mlflow: error replaying journal %s: %w", path, err)
+				}
+			}
+			err = run.LogBatch(metrics, params, tags)
+		case journalOpDeleteTag:
+			err = run.DeleteTag(entries[0].Key)
+			if errors.Is(err, ErrNotFound) {
+				// It may have been deleted by an earlier replay that crashed.
+				err = nil
+			}
+		case journalOpTerminate:
+			err = run.SetTerminated(entries[0].Status, time.UnixMilli(entries[0].EndTime))
+		default:
+			err = newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "unknown journal operation %q", entries[0].Op)
+		}
+		if err != nil && !isRejected(err) {
+			return fmt.Errorf("mlflow: error replaying journal %s: %w", path, err)
+		}
+		if err != nil && n > 1 {
+			// Find out which of the merged batches were rejected.
+			unmerged = n
+			continue
+		}
+		if err != nil {
+			if err := appendJournal(path+rejectedJournalExt, header, entries[0]); err != nil {
+				return fmt.Errorf("mlflow: error moving rejected operation out of journal %s: %w", path, err)
+			}
+			rejected++
+			if rejectedErr == nil {
+				rejectedErr = err
+			}
+		}
+		if unmerged > 0 {
+			unmerged--
+		}
+		entries = entries[n:]
+		if err := writeJournal(path, header, entries); err != nil {
+			return fmt.Errorf("mlflow: error updating journal %s: %w", path, err)
+		}
+	}
+	if rejectedErr != nil {
+		return fmt.Errorf("mlflow: %d operations in journal %s were rejected and moved to %s: %w",
+			rejected, path, path+rejectedJournalExt, rejectedErr)
+	}
+	return nil
+}
+
+// unloggedMetrics returns the metrics that aren't in the run's history.
+func unloggedMetrics(run Run, metrics []LoggedMetric) ([]LoggedMetric, error) {
+	logged := make(map[loggedMetricID]bool)
+	fetched := make(map[string]bool)
+	unlogged := make([]LoggedMetric, 0, len(metrics))
+	for _, m := range metrics {
+		if !fetched[m.Key] {
+			fetched[m.Key] = true
+			history, err := fullMetricHistory(run, m.Key)
+			if err != nil {
+				return nil, err
+			}
+			for _, h := range history {
+				logged[newLoggedMetricID(h)] = true
+			}
+		}
+		if !logged[newLoggedMetricID(m)] {
+			unlogged = append(unlogged, m)
+		}
+	}
+	return unlogged, nil
+}
+
+// mergeBatches combines batch entries, dropping duplicate metrics and all but the last value
+// of each param and tag.
+func mergeBatches(entries []journalEntry) ([]LoggedMetric, []Param, []Tag) {
+	metrics := make([]LoggedMetric, 0)
+	seenMetrics := make(map[loggedMetricID]bool)
+	params := make([]Param, 0)
+	paramIdxs := make(map[string]int)
+	tags := make([]Tag, 0)
+	tagIdxs := make(map[string]int)
+	for _, entry := range entries {
+		for _, m := range entry.Metrics {
+			k := newLoggedMetricID(m)
+			if !seenMetrics[k] {
+				seenMetrics[k] = true
+				metrics = append(metrics, m)
+			}
+		}
+		for _, p := range entry.Params {
+			if i, ok := paramIdxs[p.Key]; ok {
+				params[i] = p
+			} else {
+				paramIdxs[p.Key] = len(params)
+				params = append(params, p)
+			}
+		}
+		for _, t := range entry.Tags {
+			if i, ok := tagIdxs[t.Key]; ok {
+				tags[i] = t
+			} else {
+				tagIdxs[t.Key] = len(tags)
+				tags = append(tags, t)
+			}
+		}
+	}
+	return metrics, params, tags
+}
+
+// ReplayJournals replays the journals written by [JournaledRun] in dir, e.g. ones left behind by
+// processes that exited while the tracking server was unavailable. Each journal is removed once
+// it has been replayed. All journals are attempted, and the first error is returned.
+func ReplayJournals(tracking Tracking, dir string) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("mlflow.ReplayJournals: error reading dir: %w", err)
+	}
+	var firstErr error
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), journalFileExt) {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		err := func() error {
+			header, _, err := readJournal(path)
+			if err != nil {
+				return err
+			}
+			exp, err := tracking.GetExperiment(header.ExperimentID)
+			if err != nil {
+				return err
+			}
+			run, err := exp.GetRun(header.RunID)
+			if err != nil {
+				return err
+			}
+			return replayJournal(run, path)
+		}()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,308 @@
+package mlflow
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Astera-org/mlflow-go/protos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyRun fails with an unavailable error while down is true.
+type flakyRun struct {
+	Run
+	down *bool
+}
+
+func (r flakyRun) err() error {
+	if *r.down {
+		return &Error{Code: protos.ErrorCode_TEMPORARILY_UNAVAILABLE.String(), StatusCode: 503}
+	}
+	return nil
+}
+
+func (r flakyRun) LogBatch(metrics []LoggedMetric, params []Param, tags []Tag) error {
+	if err := r.err(); err != nil {
+		return err
+	}
+	return r.Run.LogBatch(metrics, params, tags)
+}
+
+func (r flakyRun) DeleteTag(key string) error {
+	if err := r.err(); err != nil {
+		return err
+	}
+	return r.Run.DeleteTag(key)
+}
+
+func (r flakyRun) SetTerminated(status RunStatus, endTime time.Time) error {
+	if err := r.err(); err != nil {
+		return err
+	}
+	return r.Run.SetTerminated(status, endTime)
+}
+
+func TestJournaledRun(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	down := false
+	j, err := NewJournaledRun(flakyRun{run, &down}, dir)
+	require.NoError(t, err)
+	j.replayInterval = time.Hour
+	journalPath := filepath.Join(dir, run.ID()+journalFileExt)
+
+	require.NoError(t, j.LogMetric("loss", 3, 0))
+	require.NoError(t, j.SetTag("stale", "x"))
+	assert.NoFileExists(t, journalPath)
+
+	down = true
+	require.NoError(t, j.LogMetric("loss", 2, 1))
+	require.NoError(t, j.LogParam("lr", "0.1"))
+	require.NoError(t, j.SetTag("phase", "warmup"))
+	require.NoError(t, j.SetTag("phase", "train"))
+	require.NoError(t, j.DeleteTag("stale"))
+	down = false
+	// Journaled operations must stay in order, so this is journaled too until a replay.
+	require.NoError(t, j.LogMetric("loss", 1, 2))
+	require.NoError(t, j.End())
+	endedBy := time.Now()
+	assert.FileExists(t, journalPath)
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	// The replay must record when the run ended, not when it was replayed.
+	time.Sleep(10 * time.Millisecond)
+	// An incomplete last line, as if the process crashed while writing it, is ignored.
+	f, err := os.OpenFile(journalPath, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"op": "bat`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.NoError(t, ReplayJournals(fs, dir))
+	assert.NoFileExists(t, journalPath)
+	history, _, err = run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, []float64{3, 2, 1}, []float64{history[0].Val, history[1].Val, history[2].Val})
+	val, err := run.GetParam("lr")
+	require.NoError(t, err)
+	assert.Equal(t, "0.1", val)
+	val, err = run.GetTag("phase")
+	require.NoError(t, err)
+	assert.Equal(t, "train", val)
+	_, err = run.GetTag("stale")
+	assert.ErrorIs(t, err, ErrNotFound)
+	info, err := run.Info()
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusFinished, info.Status)
+	assert.False(t, info.EndTime.After(endedBy))
+}
+
+func TestJournaledRunReplay(t *testing.T) {
+	run := newTestRun(t)
+	down := true
+	j, err := NewJournaledRun(flakyRun{run, &down}, t.TempDir())
+	require.NoError(t, err)
+	j.replayInterval = 0
+	require.NoError(t, j.LogMetric("loss", 2, 0))
+	require.NoError(t, j.LogMetric("loss", 1, 1))
+	down = false
+	require.NoError(t, j.LogMetric("loss", 0, 2))
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, []float64{2, 1, 0}, []float64{history[0].Val, history[1].Val, history[2].Val})
+	require.NoError(t, j.Replay())
+
+	// Errors that aren't caused by the server being unavailable are returned.
+	require.NoError(t, run.Delete())
+	assert.ErrorIs(t, j.LogMetric("loss", 0, 3), ErrInvalidParameter)
+}
+
+func TestJournaledRunNonFiniteMetrics(t *testing.T) {
+	run := newTestRun(t)
+	down := true
+	j, err := NewJournaledRun(flakyRun{run, &down}, t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, j.LogMetric("loss", math.Inf(1), 0))
+	require.NoError(t, j.LogMetric("loss", math.NaN(), 1))
+	down = false
+	require.NoError(t, j.Replay())
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.True(t, math.IsInf(history[0].Val, 1))
+	assert.True(t, math.IsNaN(history[1].Val))
+}
+
+// rejectingRun rejects batches with a param whose value is "bad", after logging their metrics.
+type rejectingRun struct {
+	flakyRun
+}
+
+func (r rejectingRun) LogBatch(metrics []LoggedMetric, params []Param, tags []Tag) error {
+	for _, p := range params {
+		if p.Val == "bad" {
+			if err := r.flakyRun.LogBatch(metrics, nil, nil); err != nil {
+				return err
+			}
+			return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "bad param %s", p.Key)
+		}
+	}
+	return r.flakyRun.LogBatch(metrics, params, tags)
+}
+
+func TestJournaledRunRejectedEntry(t *testing.T) {
+	run := newTestRun(t)
+	dir := t.TempDir()
+	down := true
+	j, err := NewJournaledRun(rejectingRun{flakyRun{run, &down}}, dir)
+	require.NoError(t, err)
+	j.replayInterval = 0
+	journalPath := filepath.Join(dir, run.ID()+journalFileExt)
+
+	require.NoError(t, j.LogMetric("loss", 1, 0))
+	require.NoError(t, j.LogParam("lr", "bad"))
+	require.NoError(t, j.LogMetric("loss", 2, 1))
+	require.NoError(t, j.SetTag("phase", "train"))
+	down = false
+	// The rejection is reported once, and the rest of the journal is still replayed.
+	assert.ErrorIs(t, j.LogMetric("loss", 3, 2), ErrInvalidParameter)
+	assert.NoFileExists(t, journalPath)
+	require.NoError(t, j.LogMetric("loss", 4, 3))
+	require.NoError(t, j.Replay())
+
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	vals := make([]float64, len(history))
+	for i, m := range history {
+		vals[i] = m.Val
+	}
+	assert.Equal(t, []float64{1, 2, 3, 4}, vals)
+	phase, err := run.GetTag("phase")
+	require.NoError(t, err)
+	assert.Equal(t, "train", phase)
+	_, err = run.GetParam("lr")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	header, rejected, err := readJournal(journalPath + rejectedJournalExt)
+	require.NoError(t, err)
+	assert.Equal(t, run.ID(), header.RunID)
+	require.Len(t, rejected, 1)
+	assert.Equal(t, []Param{{Key: "lr", Val: "bad"}}, rejected[0].Params)
+}
+
+func TestJournaledRunAppendAfterPartialLine(t *testing.T) {
+	run := newTestRun(t)
+	dir := t.TempDir()
+	down := true
+	j, err := NewJournaledRun(flakyRun{run, &down}, dir)
+	require.NoError(t, err)
+	require.NoError(t, j.LogMetric("loss", 2, 0))
+	// As if the process crashed while writing an entry.
+	journalPath := filepath.Join(dir, run.ID()+journalFileExt)
+	f, err := os.OpenFile(journalPath, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"op": "bat`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// After a restart, the first replay fails because the server is still down.
+	j, err = NewJournaledRun(flakyRun{run, &down}, dir)
+	require.NoError(t, err)
+	j.replayInterval = 0
+	require.NoError(t, j.LogMetric("loss", 1, 1))
+	down = false
+	require.NoError(t, j.Replay())
+	assert.NoFileExists(t, journalPath)
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, []float64{2, 1}, []float64{history[0].Val, history[1].Val})
+}
+
+func TestReplayJournalAfterCrash(t *testing.T) {
+	run := newTestRun(t)
+	dir := t.TempDir()
+	down := true
+	j, err := NewJournaledRun(flakyRun{run, &down}, dir)
+	require.NoError(t, err)
+	// NaN != NaN, but a NaN that was already sent must still be skipped.
+	require.NoError(t, j.LogMetric("loss", math.NaN(), 0))
+	require.NoError(t, j.LogMetric("loss", 1, 1))
+	// As if a replay sent the batch and crashed before it could update the journal.
+	journalPath := filepath.Join(dir, run.ID()+journalFileExt)
+	_, entries, err := readJournal(journalPath)
+	require.NoError(t, err)
+	metrics, params, tags := mergeBatches(entries)
+	require.NoError(t, run.LogBatch(metrics[:1], params, tags))
+
+	require.NoError(t, replayJournal(run, journalPath))
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.True(t, math.IsNaN(history[0].Val))
+	assert.Equal(t, 1.0, history[1].Val)
+}
+
+func TestIsUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	closedServer := httptest.NewServer(http.NotFoundHandler())
+	closedServer.Close()
+	getErr := func(ctx context.Context, url string, opts ...RESTStoreOption) error {
+		store, err := NewRESTStore(url, "", append([]RESTStoreOption{WithMaxRetries(0)}, opts...)...)
+		require.NoError(t, err)
+		_, err = store.WithContext(ctx).GetExperiment("0")
+		require.Error(t, err)
+		return err
+	}
+
+	assert.True(t, isUnavailable(getErr(context.Background(), closedServer.URL)), "connection refused")
+	assert.True(t, isUnavailable(getErr(context.Background(), server.URL, WithTimeout(10*time.Millisecond))), "client timeout")
+	assert.False(t, isUnavailable(getErr(context.Background(), tlsServer.URL)), "certificate error")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, isUnavailable(getErr(ctx, server.URL)), "cancelled")
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, isUnavailable(getErr(ctx, server.URL)), "deadline exceeded")
+	assert.True(t, isUnavailable(&Error{Code: "TEMPORARILY_UNAVAILABLE", StatusCode: http.StatusServiceUnavailable}))
+}
+
+func TestMergeBatches(t *testing.T) {
+	ts := time.UnixMilli(1000)
+	metrics, params, tags := mergeBatches([]journalEntry{
+		{Op: journalOpBatch, Metrics: []LoggedMetric{{"a", 1, 0, ts}}, Params: []Param{{"p", "1"}}, Tags: []Tag{{"t", "1"}}},
+		{Op: journalOpBatch, Metrics: []LoggedMetric{{"a", 1, 0, ts}, {"a", 1, 1, ts}}, Tags: []Tag{{"u", "1"}, {"t", "2"}}},
+	})
+	assert.Equal(t, []LoggedMetric{{"a", 1, 0, ts}, {"a", 1, 1, ts}}, metrics)
+	assert.Equal(t, []Param{{"p", "1"}}, params)
+	assert.Equal(t, []Tag{{"t", "2"}, {"u", "1"}}, tags)
+	metrics, _, _ = mergeBatches([]journalEntry{
+		{Op: journalOpBatch, Metrics: []LoggedMetric{{"a", math.NaN(), 0, ts}}},
+		{Op: journalOpBatch, Metrics: []LoggedMetric{{"a", math.NaN(), 0, ts}}},
+	})
+	assert.Len(t, metrics, 1)
+}
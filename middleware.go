@@ -0,0 +1,60 @@
+package mlflow
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// Middleware wraps the transport used for requests to the tracking server,
+// e.g. to add headers or record metrics. See [WithMiddleware].
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as an [http.RoundTripper].
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware adds middleware to requests to the tracking server.
+// The first middleware is the outermost, so it sees each request first and each response last.
+// Middleware sees every attempt of a retried request. It is not applied to artifact uploads
+// to signed URIs or to OAuth token requests, which don't go to the tracking server.
+func WithMiddleware(middleware ...Middleware) RESTStoreOption {
+	return func(rs *RESTStore) {
+		rs.middleware = append(rs.middleware, middleware...)
+	}
+}
+
+// RequestLogger returns middleware that logs the method, path, status and latency of each request to l.
+func RequestLogger(l *log.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next.RoundTrip(req)
+			latency := time.Since(start).Round(time.Millisecond)
+			if err != nil {
+				l.Printf("%s %s failed after %v: %v", req.Method, req.URL.Path, latency, err)
+			} else {
+				l.Printf("%s %s %s %v", req.Method, req.URL.Path, res.Status, latency)
+			}
+			return res, err
+		})
+	}
+}
+
+// withMiddleware returns a copy of client whose transport is wrapped by middleware.
+func withMiddleware(client *http.Client, middleware []Middleware) *http.Client {
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		transport = middleware[i](transport)
+	}
+	c := *client
+	c.Transport = transport
+	return &c
+}
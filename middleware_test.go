@@ -0,0 +1,41 @@
+package mlflow
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	var gotHeaders []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Values("X-Trace")
+		w.Write([]byte(`{"experiment": {"experiment_id": "0", "name": "Default"}}`))
+	}))
+	defer server.Close()
+
+	addHeader := func(val string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req = req.Clone(req.Context())
+				req.Header.Add("X-Trace", val)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	var logs bytes.Buffer
+	store, err := NewRESTStore(server.URL, "",
+		WithMiddleware(addHeader("outer"), addHeader("inner")),
+		WithMiddleware(RequestLogger(log.New(&logs, "", 0))))
+	require.NoError(t, err)
+	_, err = store.GetExperiment("0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, gotHeaders)
+	assert.True(t, strings.HasPrefix(logs.String(), "GET /api/2.0/mlflow/experiments/get 200 OK "), logs.String())
+}
@@ -0,0 +1,168 @@
+package mlflowtest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+type artifactFiles struct {
+	mtx sync.Mutex
+	// Keyed by slash-separated path relative to the root of the repo.
+	files map[string][]byte
+}
+
+func newArtifactFiles() *artifactFiles {
+	return &artifactFiles{files: make(map[string][]byte)}
+}
+
+// ArtifactRepo is an in-memory implementation of [mlflow.ArtifactRepo].
+// Files are read when they are logged, so they can be changed or deleted afterwards.
+type ArtifactRepo struct {
+	files *artifactFiles
+	ctx   context.Context
+}
+
+// NewArtifactRepo creates an empty repo. Runs from a [Tracking] each have their own,
+// see [Run.ArtifactRepo].
+func NewArtifactRepo() *ArtifactRepo {
+	return &ArtifactRepo{files: newArtifactFiles(), ctx: context.Background()}
+}
+
+// Implements [mlflow.ArtifactRepo.WithContext].
+func (repo *ArtifactRepo) WithContext(ctx context.Context) mlflow.ArtifactRepo {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *repo
+	c.ctx = ctx
+	return &c
+}
+
+// Implements [mlflow.ArtifactRepo.LogArtifact].
+// Like [mlflow.FileArtifactRepo], if localPath is a directory, its tree is logged under
+// artifactPath/<base name of localPath>.
+func (repo *ArtifactRepo) LogArtifact(localPath, artifactPath string) error {
+	return repo.logTree(localPath, path.Join(artifactPath, filepath.Base(localPath)))
+}
+
+// Implements [mlflow.ArtifactRepo.LogArtifacts].
+func (repo *ArtifactRepo) LogArtifacts(localDir, artifactPath string) error {
+	return repo.logTree(localDir, artifactPath)
+}
+
+// logTree logs the file at localPath to artifactPath, or if localPath is a directory,
+// each file in it to the corresponding path under artifactPath.
+func (repo *ArtifactRepo) logTree(localPath, artifactPath string) error {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(localPath, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := repo.ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		contents, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[path.Join(artifactPath, filepath.ToSlash(rel))] = contents
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	repo.files.mtx.Lock()
+	defer repo.files.mtx.Unlock()
+	for p, contents := range files {
+		repo.files.files[p] = contents
+	}
+	return nil
+}
+
+// Implements [mlflow.ArtifactRepo.ListArtifacts].
+func (repo *ArtifactRepo) ListArtifacts(artifactPath string) ([]mlflow.ArtifactInfo, error) {
+	if err := repo.ctx.Err(); err != nil {
+		return nil, err
+	}
+	prefix := path.Clean("/" + artifactPath)[1:]
+	if prefix != "" {
+		prefix += "/"
+	}
+	repo.files.mtx.Lock()
+	defer repo.files.mtx.Unlock()
+	infos := []mlflow.ArtifactInfo{}
+	dirs := make(map[string]bool)
+	for p, contents := range repo.files.files {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		name, _, isDir := strings.Cut(p[len(prefix):], "/")
+		if !isDir {
+			infos = append(infos, mlflow.ArtifactInfo{Path: p, Size: int64(len(contents))})
+		} else if !dirs[name] {
+			dirs[name] = true
+			infos = append(infos, mlflow.ArtifactInfo{Path: prefix + name, IsDir: true})
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	return infos, nil
+}
+
+// Implements [mlflow.ArtifactRepo.DownloadArtifacts].
+func (repo *ArtifactRepo) DownloadArtifacts(artifactPath, dstDir string) (string, error) {
+	return mlflow.DownloadArtifacts(repo, artifactPath, dstDir)
+}
+
+// Implements [mlflow.ArtifactRepo.OpenArtifact].
+func (repo *ArtifactRepo) OpenArtifact(artifactPath string) (io.ReadCloser, error) {
+	if err := repo.ctx.Err(); err != nil {
+		return nil, err
+	}
+	contents, err := repo.Artifact(path.Clean("/" + artifactPath)[1:])
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(contents)), nil
+}
+
+// Artifact returns the contents of the artifact at path, which is relative to the root of
+// the repo, e.g. "checkpoints/model.bin".
+func (repo *ArtifactRepo) Artifact(path string) ([]byte, error) {
+	repo.files.mtx.Lock()
+	defer repo.files.mtx.Unlock()
+	contents, ok := repo.files.files[path]
+	if !ok {
+		return nil, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no artifact at %s", path)
+	}
+	return contents, nil
+}
+
+// Paths returns the paths of all artifacts in the repo, sorted.
+func (repo *ArtifactRepo) Paths() []string {
+	repo.files.mtx.Lock()
+	defer repo.files.mtx.Unlock()
+	paths := make([]string, 0, len(repo.files.files))
+	for p := range repo.files.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
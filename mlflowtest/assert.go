@@ -0,0 +1,110 @@
+package mlflowtest
+
+import (
+	"bytes"
+	"testing"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+)
+
+// Each Assert function reports a failure with t.Errorf, so the test continues, and returns
+// true if the assertion holds, like the functions in github.com/stretchr/testify/assert.
+
+// AssertMetric checks that the metric key was logged to run at step with value val.
+func AssertMetric(t testing.TB, run mlflow.Run, key string, step int64, val float64) bool {
+	t.Helper()
+	var valsAtStep []float64
+	pageToken := ""
+	for {
+		history, nextPageToken, err := run.GetMetricHistory(key, pageToken)
+		if err != nil {
+			t.Errorf("error getting history of metric %q of run %s: %v", key, run.ID(), err)
+			return false
+		}
+		for _, m := range history {
+			if m.Step != step {
+				continue
+			}
+			if m.Val == val {
+				return true
+			}
+			valsAtStep = append(valsAtStep, m.Val)
+		}
+		if nextPageToken == "" {
+			break
+		}
+		pageToken = nextPageToken
+	}
+	if len(valsAtStep) == 0 {
+		t.Errorf("metric %q of run %s was not logged at step %d, want %v", key, run.ID(), step, val)
+	} else {
+		t.Errorf("metric %q of run %s was logged at step %d with %v, want %v", key, run.ID(), step, valsAtStep, val)
+	}
+	return false
+}
+
+// AssertParam checks that the param key of run has value val.
+func AssertParam(t testing.TB, run mlflow.Run, key, val string) bool {
+	t.Helper()
+	got, err := run.GetParam(key)
+	if err != nil {
+		t.Errorf("error getting param %q of run %s: %v", key, run.ID(), err)
+		return false
+	}
+	if got != val {
+		t.Errorf("param %q of run %s is %q, want %q", key, run.ID(), got, val)
+		return false
+	}
+	return true
+}
+
+// AssertTag checks that the tag key of run has value val.
+func AssertTag(t testing.TB, run mlflow.Run, key, val string) bool {
+	t.Helper()
+	got, err := run.GetTag(key)
+	if err != nil {
+		t.Errorf("error getting tag %q of run %s: %v", key, run.ID(), err)
+		return false
+	}
+	if got != val {
+		t.Errorf("tag %q of run %s is %q, want %q", key, run.ID(), got, val)
+		return false
+	}
+	return true
+}
+
+// AssertStatus checks that run has the given status, e.g. [mlflow.RunStatusFinished] if it ended.
+func AssertStatus(t testing.TB, run mlflow.Run, status mlflow.RunStatus) bool {
+	t.Helper()
+	info, err := run.Info()
+	if err != nil {
+		t.Errorf("error getting info of run %s: %v", run.ID(), err)
+		return false
+	}
+	if info.Status != status {
+		t.Errorf("status of run %s is %v, want %v", run.ID(), info.Status, status)
+		return false
+	}
+	return true
+}
+
+// AssertArtifact checks that the artifact at path, relative to the root of the run's artifacts,
+// has the given contents. run must be a [Run] from this package.
+func AssertArtifact(t testing.TB, run mlflow.Run, path string, contents []byte) bool {
+	t.Helper()
+	memRun, ok := run.(*Run)
+	if !ok {
+		t.Errorf("AssertArtifact requires a *mlflowtest.Run, got %T", run)
+		return false
+	}
+	got, err := memRun.Artifact(path)
+	if err != nil {
+		t.Errorf("error getting artifact %s of run %s: %v", path, run.ID(), err)
+		return false
+	}
+	if !bytes.Equal(got, contents) {
+		t.Errorf("artifact %s of run %s is %q, want %q", path, run.ID(), got, contents)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package mlflowtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+// Experiment is an in-memory implementation of [mlflow.Experiment].
+type Experiment struct {
+	store *store
+	ctx   context.Context
+	id    string
+}
+
+// Implements [mlflow.Experiment.WithContext].
+func (exp *Experiment) WithContext(ctx context.Context) mlflow.Experiment {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *exp
+	c.ctx = ctx
+	return &c
+}
+
+// data locks the store and returns the experiment's data. The caller must unlock the store.
+func (exp *Experiment) data() (*experimentData, error) {
+	if err := exp.store.lock(exp.ctx); err != nil {
+		return nil, err
+	}
+	return exp.store.experiments[exp.id], nil
+}
+
+// activeData is like data, but fails if the experiment is deleted.
+// The store is unlocked if there is an error.
+func (exp *Experiment) activeData() (*experimentData, error) {
+	data, err := exp.data()
+	if err != nil {
+		return nil, err
+	}
+	if data.lifecycleStage != mlflow.LifecycleStageActive {
+		exp.store.mtx.Unlock()
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "experiment %s is not active", data.name)
+	}
+	return data, nil
+}
+
+func (exp *Experiment) ID() string {
+	return exp.id
+}
+
+func (exp *Experiment) Name() string {
+	exp.store.mtx.Lock()
+	defer exp.store.mtx.Unlock()
+	return exp.store.experiments[exp.id].name
+}
+
+// Implements [mlflow.Experiment.SetName].
+func (exp *Experiment) SetName(name string) error {
+	if name == "" {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "experiment name must not be empty")
+	}
+	data, err := exp.activeData()
+	if err != nil {
+		return err
+	}
+	defer exp.store.mtx.Unlock()
+	for _, other := range exp.store.experiments {
+		if other.name == name && other.id != exp.id {
+			return newError(protos.ErrorCode_RESOURCE_ALREADY_EXISTS, "experiment with name %q already exists", name)
+		}
+	}
+	data.name = name
+	data.lastUpdateTime = exp.store.now()
+	return nil
+}
+
+// Implements [mlflow.Experiment.SetTag].
+func (exp *Experiment) SetTag(key, value string) error {
+	data, err := exp.activeData()
+	if err != nil {
+		return err
+	}
+	defer exp.store.mtx.Unlock()
+	data.tags[key] = value
+	return nil
+}
+
+// Implements [mlflow.Experiment.GetTag].
+func (exp *Experiment) GetTag(key string) (string, error) {
+	data, err := exp.data()
+	if err != nil {
+		return "", err
+	}
+	defer exp.store.mtx.Unlock()
+	val, ok := data.tags[key]
+	if !ok {
+		return "", newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no tag with key %s", key)
+	}
+	return val, nil
+}
+
+// Implements [mlflow.Experiment.DeleteTag].
+func (exp *Experiment) DeleteTag(key string) error {
+	data, err := exp.data()
+	if err != nil {
+		return err
+	}
+	defer exp.store.mtx.Unlock()
+	if _, ok := data.tags[key]; !ok {
+		return newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no tag with key %s", key)
+	}
+	delete(data.tags, key)
+	return nil
+}
+
+// Implements [mlflow.Experiment.Info].
+func (exp *Experiment) Info() (mlflow.ExperimentInfo, error) {
+	data, err := exp.data()
+	if err != nil {
+		return mlflow.ExperimentInfo{}, err
+	}
+	defer exp.store.mtx.Unlock()
+	info := mlflow.ExperimentInfo{
+		ID:               data.id,
+		Name:             data.name,
+		ArtifactLocation: exp.store.artifactRoot + data.id,
+		LifecycleStage:   data.lifecycleStage,
+		CreationTime:     data.creationTime,
+		LastUpdateTime:   data.lastUpdateTime,
+		Tags:             make([]mlflow.Tag, 0, len(data.tags)),
+	}
+	for key, val := range data.tags {
+		info.Tags = append(info.Tags, mlflow.Tag{Key: key, Val: val})
+	}
+	sort.Slice(info.Tags, func(i, j int) bool { return info.Tags[i].Key < info.Tags[j].Key })
+	return info, nil
+}
+
+// Implements [mlflow.Experiment.CreateRun].
+// Run IDs are sequential, and the default run name is the first 8 characters of the ID, like [mlflow.FileStore].
+func (exp *Experiment) CreateRun(name string, opts ...mlflow.CreateRunOption) (mlflow.Run, error) {
+	startTime, tags := mlflow.CreateRunOptionValues(opts...)
+	data, err := exp.activeData()
+	if err != nil {
+		return nil, err
+	}
+	defer exp.store.mtx.Unlock()
+	exp.store.nextRunID++
+	runID := fmt.Sprintf("%032x", exp.store.nextRunID)
+	if name == "" {
+		name = runID[0:8]
+	}
+	if startTime.IsZero() {
+		startTime = exp.store.now()
+	}
+	run := &runData{
+		info: mlflow.RunInfo{
+			ID:             runID,
+			Name:           name,
+			ExperimentID:   data.id,
+			Status:         mlflow.RunStatusRunning,
+			StartTime:      truncate(startTime),
+			ArtifactURI:    fmt.Sprintf("%s%s/%s/artifacts", exp.store.artifactRoot, data.id, runID),
+			LifecycleStage: mlflow.LifecycleStageActive,
+		},
+		params:    make(map[string]string),
+		tags:      make(map[string]string),
+		metrics:   make(map[string][]mlflow.LoggedMetric),
+		artifacts: newArtifactFiles(),
+	}
+	for _, tag := range tags {
+		run.setTag(tag.Key, tag.Val)
+	}
+	exp.store.runs[runID] = run
+	return &Run{store: exp.store, ctx: exp.ctx, id: runID}, nil
+}
+
+func (exp *Experiment) GetRun(runID string) (mlflow.Run, error) {
+	if runID == "" {
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "runID is empty")
+	}
+	if err := exp.store.lock(exp.ctx); err != nil {
+		return nil, err
+	}
+	defer exp.store.mtx.Unlock()
+	run, ok := exp.store.runs[runID]
+	if !ok || run.info.ExperimentID != exp.id {
+		return nil, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no run with id %s in experiment %s", runID, exp.id)
+	}
+	return &Run{store: exp.store, ctx: exp.ctx, id: runID}, nil
+}
+
+// Implements [mlflow.Experiment.Delete].
+func (exp *Experiment) Delete() error {
+	data, err := exp.data()
+	if err != nil {
+		return err
+	}
+	defer exp.store.mtx.Unlock()
+	if data.lifecycleStage == mlflow.LifecycleStageDeleted {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "experiment %s is already deleted", data.name)
+	}
+	exp.setLifecycleStage(data, mlflow.LifecycleStageDeleted)
+	return nil
+}
+
+// Implements [mlflow.Experiment.Restore].
+func (exp *Experiment) Restore() error {
+	data, err := exp.data()
+	if err != nil {
+		return err
+	}
+	defer exp.store.mtx.Unlock()
+	if data.lifecycleStage != mlflow.LifecycleStageDeleted {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "experiment %s is not deleted", data.name)
+	}
+	exp.setLifecycleStage(data, mlflow.LifecycleStageActive)
+	return nil
+}
+
+// setLifecycleStage sets the lifecycle stage of the experiment and all of its runs.
+// Must be called with the store locked.
+func (exp *Experiment) setLifecycleStage(data *experimentData, lifecycleStage string) {
+	data.lifecycleStage = lifecycleStage
+	data.lastUpdateTime = exp.store.now()
+	for _, run := range exp.store.runs {
+		if run.info.ExperimentID == exp.id {
+			run.info.LifecycleStage = lifecycleStage
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package mlflowtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracking(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	tracking := NewTracking(WithClock(func() time.Time { return now }))
+
+	exp, err := tracking.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	assert.Equal(t, "1", exp.ID())
+	_, err = tracking.CreateExperiment("exp0")
+	assert.ErrorIs(t, err, mlflow.ErrAlreadyExists)
+	def, err := tracking.GetExperiment("")
+	require.NoError(t, err)
+	assert.Equal(t, "Default", def.Name())
+	_, err = tracking.GetExperiment("missing")
+	assert.ErrorIs(t, err, mlflow.ErrNotFound)
+	require.NoError(t, exp.SetTag("team", "vision"))
+	info, err := exp.Info()
+	require.NoError(t, err)
+	assert.Equal(t, []mlflow.Tag{{Key: "team", Val: "vision"}}, info.Tags)
+
+	run, err := exp.CreateRun("run0", mlflow.WithTags([]mlflow.Tag{{Key: mlflow.UserTagKey, Val: "alice"}}))
+	require.NoError(t, err)
+	require.NoError(t, run.LogMetric("loss", 2, 0))
+	require.NoError(t, run.LogMetrics([]mlflow.Metric{{Key: "loss", Val: 1}}, 1))
+	require.NoError(t, run.LogParam("lr", "0.1"))
+	require.NoError(t, run.LogParam("lr", "0.1"))
+	assert.ErrorIs(t, run.LogParam("lr", "0.2"), mlflow.ErrInvalidParameter)
+	require.NoError(t, run.SetTag("stage", "train"))
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	assert.Equal(t, []mlflow.LoggedMetric{
+		{Key: "loss", Val: 2, Step: 0, Timestamp: now},
+		{Key: "loss", Val: 1, Step: 1, Timestamp: now},
+	}, history)
+	latest, err := run.GetLatestMetrics()
+	require.NoError(t, err)
+	assert.Equal(t, []mlflow.LoggedMetric{{Key: "loss", Val: 1, Step: 1, Timestamp: now}}, latest)
+
+	localPath := filepath.Join(t.TempDir(), "model.bin")
+	require.NoError(t, os.WriteFile(localPath, []byte("weights"), 0644))
+	require.NoError(t, run.LogArtifact(localPath, "checkpoints"))
+	require.NoError(t, run.End())
+
+	AssertMetric(t, run, "loss", 1, 1)
+	AssertParam(t, run, "lr", "0.1")
+	AssertTag(t, run, "stage", "train")
+	AssertStatus(t, run, mlflow.RunStatusFinished)
+	AssertArtifact(t, run, "checkpoints/model.bin", []byte("weights"))
+	infos, err := run.ListArtifacts("")
+	require.NoError(t, err)
+	assert.Equal(t, []mlflow.ArtifactInfo{{Path: "checkpoints", IsDir: true}}, infos)
+	infos, err = run.ListArtifacts("checkpoints")
+	require.NoError(t, err)
+	assert.Equal(t, []mlflow.ArtifactInfo{{Path: "checkpoints/model.bin", Size: 7}}, infos)
+	dstDir := t.TempDir()
+	downloaded, err := run.DownloadArtifacts("checkpoints/model.bin", dstDir)
+	require.NoError(t, err)
+	contents, err := os.ReadFile(downloaded)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("weights"), contents)
+
+	runInfo, err := run.Info()
+	require.NoError(t, err)
+	assert.Equal(t, "alice", runInfo.UserID)
+	assert.Equal(t, now, runInfo.EndTime)
+
+	runs, _, err := tracking.SearchRuns([]string{exp.ID()}, "params.lr = '0.1' and metrics.loss < 2", nil, "")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, run.ID(), runs[0].ID())
+	_, _, err = tracking.SearchRuns([]string{exp.ID()}, "params.lr ==", nil, "")
+	assert.ErrorIs(t, err, mlflow.ErrInvalidParameter)
+
+	require.NoError(t, exp.Delete())
+	runs, _, err = tracking.SearchRuns([]string{exp.ID()}, "", nil, "")
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+	_, err = tracking.GetOrCreateExperimentWithName("exp0")
+	assert.ErrorIs(t, err, mlflow.ErrAlreadyExists)
+	exps, err := tracking.ExperimentsByName(mlflow.WithViewType(mlflow.ViewTypeDeletedOnly))
+	require.NoError(t, err)
+	assert.Contains(t, exps, "exp0")
+	require.NoError(t, exp.Restore())
+	got, err := tracking.GetRun(run.ID())
+	require.NoError(t, err)
+	gotInfo, err := got.Info()
+	require.NoError(t, err)
+	assert.Equal(t, mlflow.LifecycleStageActive, gotInfo.LifecycleStage)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, run.WithContext(ctx).LogMetric("loss", 0, 2), context.Canceled)
+}
+
+// recordingT records failures instead of failing the test.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertFailures(t *testing.T) {
+	exp, err := NewTracking().GetExperiment("0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	require.NoError(t, run.LogMetric("loss", 2, 0))
+
+	rt := &recordingT{TB: t}
+	assert.False(t, AssertMetric(rt, run, "loss", 0, 1))
+	assert.False(t, AssertMetric(rt, run, "loss", 1, 2))
+	assert.False(t, AssertParam(rt, run, "lr", "0.1"))
+	assert.False(t, AssertTag(rt, run, "stage", "train"))
+	assert.False(t, AssertStatus(rt, run, mlflow.RunStatusFinished))
+	assert.False(t, AssertArtifact(rt, run, "model.bin", nil))
+	assert.Equal(t, []string{
+		`metric "loss" of run 00000000000000000000000000000001 was logged at step 0 with [2], want 1`,
+		`metric "loss" of run 00000000000000000000000000000001 was not logged at step 1, want 2`,
+		`error getting param "lr" of run 00000000000000000000000000000001: RESOURCE_DOES_NOT_EXIST: no param with key lr`,
+		`error getting tag "stage" of run 00000000000000000000000000000001: RESOURCE_DOES_NOT_EXIST: no tag with key stage`,
+		`status of run 00000000000000000000000000000001 is RUNNING, want FINISHED`,
+		`error getting artifact model.bin of run 00000000000000000000000000000001: RESOURCE_DOES_NOT_EXIST: no artifact at model.bin`,
+	}, rt.errors)
+}
+
+func TestConcurrentLogging(t *testing.T) {
+	tracking := NewTracking()
+	exp, err := tracking.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+
+	const numWorkers = 8
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, run.LogMetric("loss", float64(i), int64(i)))
+			assert.NoError(t, run.SetTag(fmt.Sprintf("worker%d", i), "done"))
+			_, err := exp.CreateRun("")
+			assert.NoError(t, err)
+			_, _, err = tracking.SearchRuns([]string{exp.ID()}, "", nil, "")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < numWorkers; i++ {
+		AssertMetric(t, run, "loss", int64(i), float64(i))
+	}
+	assert.Len(t, tracking.Runs(), numWorkers+1)
+}
+
+// Checks that the in-memory store can be used in place of a real one.
+func TestSyncFromFileStore(t *testing.T) {
+	src, err := mlflow.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	srcExp, err := src.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	srcRun, err := srcExp.CreateRun("run0")
+	require.NoError(t, err)
+	require.NoError(t, srcRun.LogMetric("loss", 1.5, 3))
+	require.NoError(t, srcRun.LogParam("lr", "0.1"))
+	localPath := filepath.Join(t.TempDir(), "model.bin")
+	require.NoError(t, os.WriteFile(localPath, []byte("weights"), 0644))
+	require.NoError(t, srcRun.LogArtifact(localPath, "checkpoints"))
+	require.NoError(t, srcRun.End())
+
+	dst := NewTracking()
+	result, err := mlflow.Sync(src, dst, mlflow.SyncOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.CreatedRunIDs, 1)
+	run, err := dst.GetRun(result.CreatedRunIDs[0])
+	require.NoError(t, err)
+	AssertMetric(t, run, "loss", 3, 1.5)
+	AssertParam(t, run, "lr", "0.1")
+	AssertTag(t, run, mlflow.SourceRunIDTagKey, srcRun.ID())
+	AssertStatus(t, run, mlflow.RunStatusFinished)
+	AssertArtifact(t, run, "checkpoints/model.bin", []byte("weights"))
+}
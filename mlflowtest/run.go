@@ -0,0 +1,382 @@
+package mlflowtest
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+type runData struct {
+	info    mlflow.RunInfo
+	params  map[string]string
+	tags    map[string]string
+	metrics map[string][]mlflow.LoggedMetric
+	// Not protected by the store's mutex, since uploads can take a while.
+	artifacts *artifactFiles
+}
+
+func (data *runData) setTag(key, value string) {
+	data.tags[key] = value
+	if key == mlflow.UserTagKey {
+		data.info.UserID = value
+	}
+}
+
+// truncate rounds t down to milliseconds, which is what tracking servers store.
+func truncate(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.UnixMilli(t.UnixMilli())
+}
+
+// Run is an in-memory implementation of [mlflow.Run].
+type Run struct {
+	store *store
+	ctx   context.Context
+	id    string
+}
+
+// Implements [mlflow.Run.WithContext].
+func (r *Run) WithContext(ctx context.Context) mlflow.Run {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *r
+	c.ctx = ctx
+	return &c
+}
+
+// data locks the store and returns the run's data. The caller must unlock the store.
+func (r *Run) data() (*runData, error) {
+	if err := r.store.lock(r.ctx); err != nil {
+		return nil, err
+	}
+	return r.store.runs[r.id], nil
+}
+
+// activeData is like data, but fails if the run is deleted.
+// The store is unlocked if there is an error.
+func (r *Run) activeData() (*runData, error) {
+	data, err := r.data()
+	if err != nil {
+		return nil, err
+	}
+	if data.info.LifecycleStage != mlflow.LifecycleStageActive {
+		r.store.mtx.Unlock()
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "run %s is not active", data.info.Name)
+	}
+	return data, nil
+}
+
+func (r *Run) ID() string {
+	return r.id
+}
+
+func (r *Run) ExperimentID() string {
+	r.store.mtx.Lock()
+	defer r.store.mtx.Unlock()
+	return r.store.runs[r.id].info.ExperimentID
+}
+
+func (r *Run) UIURL() string {
+	return ""
+}
+
+func (r *Run) Name() string {
+	r.store.mtx.Lock()
+	defer r.store.mtx.Unlock()
+	return r.store.runs[r.id].info.Name
+}
+
+func (r *Run) SetName(name string) error {
+	data, err := r.activeData()
+	if err != nil {
+		return err
+	}
+	defer r.store.mtx.Unlock()
+	data.info.Name = name
+	return nil
+}
+
+// Implements [mlflow.Run.Info].
+func (r *Run) Info() (mlflow.RunInfo, error) {
+	data, err := r.data()
+	if err != nil {
+		return mlflow.RunInfo{}, err
+	}
+	defer r.store.mtx.Unlock()
+	return data.info, nil
+}
+
+func (r *Run) SetTag(key, value string) error {
+	return r.SetTags([]mlflow.Tag{{Key: key, Val: value}})
+}
+
+func (r *Run) SetTags(tags []mlflow.Tag) error {
+	data, err := r.activeData()
+	if err != nil {
+		return err
+	}
+	defer r.store.mtx.Unlock()
+	for _, tag := range tags {
+		data.setTag(tag.Key, tag.Val)
+	}
+	return nil
+}
+
+func (r *Run) GetTag(key string) (string, error) {
+	data, err := r.data()
+	if err != nil {
+		return "", err
+	}
+	defer r.store.mtx.Unlock()
+	val, ok := data.tags[key]
+	if !ok {
+		return "", newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no tag with key %s", key)
+	}
+	return val, nil
+}
+
+// Implements [mlflow.Run.DeleteTag].
+func (r *Run) DeleteTag(key string) error {
+	data, err := r.activeData()
+	if err != nil {
+		return err
+	}
+	defer r.store.mtx.Unlock()
+	if _, ok := data.tags[key]; !ok {
+		return newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no tag with key %s", key)
+	}
+	delete(data.tags, key)
+	return nil
+}
+
+// Implements [mlflow.Run.Tags].
+func (r *Run) Tags() ([]mlflow.Tag, error) {
+	data, err := r.data()
+	if err != nil {
+		return nil, err
+	}
+	defer r.store.mtx.Unlock()
+	tags := make([]mlflow.Tag, 0, len(data.tags))
+	for key, val := range data.tags {
+		tags = append(tags, mlflow.Tag{Key: key, Val: val})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags, nil
+}
+
+func (r *Run) LogParam(key, value string) error {
+	return r.LogParams([]mlflow.Param{{Key: key, Val: value}})
+}
+
+// Implements [mlflow.Run.LogParams].
+// Like a tracking server, it is an error to change the value of a param.
+// If any param can't be logged, none are.
+func (r *Run) LogParams(params []mlflow.Param) error {
+	data, err := r.activeData()
+	if err != nil {
+		return err
+	}
+	defer r.store.mtx.Unlock()
+	return data.logParams(params)
+}
+
+// logParams must be called with the store locked.
+func (data *runData) logParams(params []mlflow.Param) error {
+	for _, param := range params {
+		if old, ok := data.params[param.Key]; ok && old != param.Val {
+			return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE,
+				"changing param values is not allowed. Param with key %q was already logged with value %q for run %s. Attempted logging new value %q",
+				param.Key, old, data.info.ID, param.Val)
+		}
+	}
+	for _, param := range params {
+		data.params[param.Key] = param.Val
+	}
+	return nil
+}
+
+func (r *Run) GetParam(key string) (string, error) {
+	data, err := r.data()
+	if err != nil {
+		return "", err
+	}
+	defer r.store.mtx.Unlock()
+	val, ok := data.params[key]
+	if !ok {
+		return "", newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no param with key %s", key)
+	}
+	return val, nil
+}
+
+// Implements [mlflow.Run.Params].
+func (r *Run) Params() ([]mlflow.Param, error) {
+	data, err := r.data()
+	if err != nil {
+		return nil, err
+	}
+	defer r.store.mtx.Unlock()
+	params := make([]mlflow.Param, 0, len(data.params))
+	for key, val := range data.params {
+		params = append(params, mlflow.Param{Key: key, Val: val})
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Key < params[j].Key })
+	return params, nil
+}
+
+func (r *Run) LogMetric(key string, val float64, step int64) error {
+	return r.LogBatch([]mlflow.LoggedMetric{{Key: key, Val: val, Step: step}}, nil, nil)
+}
+
+func (r *Run) LogMetrics(metrics []mlflow.Metric, step int64) error {
+	logged := make([]mlflow.LoggedMetric, len(metrics))
+	for i, m := range metrics {
+		logged[i] = mlflow.LoggedMetric{Key: m.Key, Val: m.Val, Step: step}
+	}
+	return r.LogBatch(logged, nil, nil)
+}
+
+// Implements [mlflow.Run.LogBatch].
+// Metrics with a zero Timestamp are logged with the time from the store's clock.
+func (r *Run) LogBatch(metrics []mlflow.LoggedMetric, params []mlflow.Param, tags []mlflow.Tag) error {
+	data, err := r.activeData()
+	if err != nil {
+		return err
+	}
+	defer r.store.mtx.Unlock()
+	if err := data.logParams(params); err != nil {
+		return err
+	}
+	now := truncate(r.store.now())
+	for _, m := range metrics {
+		m.Timestamp = truncate(m.Timestamp)
+		if m.Timestamp.IsZero() {
+			m.Timestamp = now
+		}
+		data.metrics[m.Key] = append(data.metrics[m.Key], m)
+	}
+	for _, tag := range tags {
+		data.setTag(tag.Key, tag.Val)
+	}
+	return nil
+}
+
+// Implements [mlflow.Run.GetMetricHistory].
+// The whole history is returned in a single page.
+func (r *Run) GetMetricHistory(key, pageToken string) ([]mlflow.LoggedMetric, string, error) {
+	data, err := r.data()
+	if err != nil {
+		return nil, "", err
+	}
+	defer r.store.mtx.Unlock()
+	history := make([]mlflow.LoggedMetric, len(data.metrics[key]))
+	copy(history, data.metrics[key])
+	return history, "", nil
+}
+
+// Implements [mlflow.Run.GetLatestMetrics].
+// The latest value is the one with the highest (step, timestamp, value), like a tracking server.
+func (r *Run) GetLatestMetrics() ([]mlflow.LoggedMetric, error) {
+	data, err := r.data()
+	if err != nil {
+		return nil, err
+	}
+	defer r.store.mtx.Unlock()
+	metrics := make([]mlflow.LoggedMetric, 0, len(data.metrics))
+	for _, history := range data.metrics {
+		latest := history[0]
+		for _, m := range history[1:] {
+			if m.Step > latest.Step ||
+				(m.Step == latest.Step && (m.Timestamp.After(latest.Timestamp) ||
+					(m.Timestamp.Equal(latest.Timestamp) && m.Val > latest.Val))) {
+				latest = m
+			}
+		}
+		metrics = append(metrics, latest)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Key < metrics[j].Key })
+	return metrics, nil
+}
+
+func (r *Run) End() error {
+	return r.SetTerminated(mlflow.RunStatusFinished, r.store.now())
+}
+
+func (r *Run) Fail() error {
+	return r.SetTerminated(mlflow.RunStatusFailed, r.store.now())
+}
+
+// Implements [mlflow.Run.SetTerminated].
+func (r *Run) SetTerminated(status mlflow.RunStatus, endTime time.Time) error {
+	data, err := r.activeData()
+	if err != nil {
+		return err
+	}
+	defer r.store.mtx.Unlock()
+	data.info.Status = status
+	data.info.EndTime = truncate(endTime)
+	return nil
+}
+
+// Implements [mlflow.Run.Delete].
+func (r *Run) Delete() error {
+	data, err := r.activeData()
+	if err != nil {
+		return err
+	}
+	defer r.store.mtx.Unlock()
+	data.info.LifecycleStage = mlflow.LifecycleStageDeleted
+	return nil
+}
+
+// Implements [mlflow.Run.Restore].
+func (r *Run) Restore() error {
+	data, err := r.data()
+	if err != nil {
+		return err
+	}
+	defer r.store.mtx.Unlock()
+	if data.info.LifecycleStage != mlflow.LifecycleStageDeleted {
+		return newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "run %s is not deleted", data.info.Name)
+	}
+	data.info.LifecycleStage = mlflow.LifecycleStageActive
+	return nil
+}
+
+// ArtifactRepo returns the repo that holds the run's artifacts.
+func (r *Run) ArtifactRepo() *ArtifactRepo {
+	r.store.mtx.Lock()
+	defer r.store.mtx.Unlock()
+	return &ArtifactRepo{files: r.store.runs[r.id].artifacts, ctx: r.ctx}
+}
+
+func (r *Run) LogArtifact(localPath, artifactPath string) error {
+	return r.ArtifactRepo().LogArtifact(localPath, artifactPath)
+}
+
+// Implements [mlflow.Run.ListArtifacts].
+func (r *Run) ListArtifacts(artifactPath string) ([]mlflow.ArtifactInfo, error) {
+	return r.ArtifactRepo().ListArtifacts(artifactPath)
+}
+
+// Implements [mlflow.Run.DownloadArtifacts].
+func (r *Run) DownloadArtifacts(artifactPath, dstDir string) (string, error) {
+	return r.ArtifactRepo().DownloadArtifacts(artifactPath, dstDir)
+}
+
+// Implements [mlflow.Run.OpenArtifact].
+func (r *Run) OpenArtifact(artifactPath string) (io.ReadCloser, error) {
+	return r.ArtifactRepo().OpenArtifact(artifactPath)
+}
+
+// Artifact returns the contents of the artifact at path, which is relative to the root of
+// the run's artifacts, e.g. "checkpoints/model.bin".
+func (r *Run) Artifact(path string) ([]byte, error) {
+	return r.ArtifactRepo().Artifact(path)
+}
@@ -0,0 +1,497 @@
+package mlflowtest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+const (
+	apiPrefix = "/api/2.0/mlflow/"
+	// Signed URIs for artifact uploads point here, followed by <run ID>/<artifact path>.
+	uploadPrefix = "/upload/"
+	// Signed URIs for multipart uploads point here, followed by <upload ID>/<part number>
+	// for parts, or <upload ID> for aborting.
+	uploadPartPrefix  = "/upload-part/"
+	uploadAbortPrefix = "/upload-abort/"
+	// Signed URIs for artifact downloads point here, followed by <run ID>/<artifact path>.
+	downloadPrefix = "/download/"
+	// The header that requests to signed URIs must have, like Azure Blob Storage's x-ms-blob-type for uploads.
+	uploadHeaderName  = "x-ms-blob-type"
+	uploadHeaderValue = "BlockBlob"
+	// Like the artifact root of a Databricks workspace.
+	serverArtifactRoot = "dbfs:/databricks/mlflow-tracking"
+)
+
+// Request is a request received by a [Server].
+type Request struct {
+	Method string
+	// Relative to /api/2.0/mlflow/ for tracking requests, e.g. "runs/log-batch".
+	// Artifact uploads and downloads have the full path, e.g. /upload/<run ID>/<artifact path>.
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+type fault struct {
+	path       string
+	remaining  int
+	statusCode int
+	code       protos.ErrorCode
+}
+
+// Server is a fake MLFlow tracking server, for testing clients such as [mlflow.RESTStore]
+// without running the Python server. It serves a [Tracking] with [mlflow.NewHandler],
+// records the requests it receives, and can be told to fail or delay requests.
+//
+// Artifact locations use the dbfs scheme, and the server implements the Databricks
+// artifacts/credentials-for-write, artifacts/credentials-for-read and multipart upload endpoints,
+// with signed URIs that upload to and download from the server itself.
+// Uploaded files can be checked with [AssertArtifact].
+type Server struct {
+	*httptest.Server
+	// Everything logged through the server.
+	Tracking *Tracking
+
+	handler  http.Handler
+	mtx      sync.Mutex
+	requests []Request
+	faults   []*fault
+	latency  time.Duration
+	pageSize int
+	// Multipart uploads that have been created but not completed or aborted, by upload ID.
+	uploads      map[string]*multipartUpload
+	nextUploadID int
+}
+
+type multipartUpload struct {
+	runID    string
+	path     string
+	numParts int64
+	parts    map[int64][]byte
+}
+
+// NewServer starts a server backed by a new [Tracking] created with opts.
+// The caller should call Close when finished, to shut it down.
+func NewServer(opts ...Option) *Server {
+	tracking := NewTracking(append([]Option{WithArtifactRoot(serverArtifactRoot)}, opts...)...)
+	s := &Server{
+		Tracking: tracking,
+		handler:  mlflow.NewHandler(tracking),
+		uploads:  make(map[string]*multipartUpload),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Requests returns the requests received for path, e.g. "runs/log-batch", in the order they
+// were received. If path is empty, all requests are returned.
+func (s *Server) Requests(path string) []Request {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	requests := make([]Request, 0, len(s.requests))
+	for _, req := range s.requests {
+		if path == "" || req.Path == path {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// Fail makes the next n requests for path fail with the HTTP status statusCode and an
+// MLFlow error response with code, without being handled. If path is empty, any request can fail.
+// Failures for the same path happen in the order they were added.
+func (s *Server) Fail(path string, n int, statusCode int, code protos.ErrorCode) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.faults = append(s.faults, &fault{path: path, remaining: n, statusCode: statusCode, code: code})
+}
+
+// SetLatency delays the response to every request by d, or until the request is cancelled.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.latency = d
+}
+
+// SetPageSize limits the number of results in each page of experiments/search and runs/search
+// responses to n, to test pagination without creating lots of experiments or runs.
+// Zero removes the limit.
+func (s *Server) SetPageSize(n int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.pageSize = n
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := Request{
+		Method: r.Method,
+		Path:   strings.TrimPrefix(r.URL.Path, apiPrefix),
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+
+	s.mtx.Lock()
+	s.requests = append(s.requests, req)
+	latency, pageSize := s.latency, s.pageSize
+	var failure *fault
+	for _, f := range s.faults {
+		if f.remaining > 0 && (f.path == "" || f.path == req.Path) {
+			f.remaining--
+			failure = f
+			break
+		}
+	}
+	s.mtx.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if failure != nil {
+		writeError(w, failure.statusCode, failure.code, "injected failure")
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, uploadPrefix):
+		s.serveUpload(w, r, body)
+		return
+	case strings.HasPrefix(r.URL.Path, uploadPartPrefix):
+		s.serveUploadPart(w, r, body)
+		return
+	case strings.HasPrefix(r.URL.Path, uploadAbortPrefix):
+		s.serveAbortUpload(w, r)
+		return
+	case strings.HasPrefix(r.URL.Path, downloadPrefix):
+		s.serveDownload(w, r)
+		return
+	case req.Path == "artifacts/credentials-for-read":
+		s.serveCredentialsForRead(w, body)
+		return
+	case req.Path == "artifacts/credentials-for-write":
+		s.serveCredentialsForWrite(w, body)
+		return
+	case req.Path == "artifacts/create-multipart-upload":
+		s.serveCreateMultipartUpload(w, body)
+		return
+	case req.Path == "artifacts/get-presigned-upload-part-url":
+		s.serveGetPresignedUploadPartURL(w, req.Query)
+		return
+	case req.Path == "artifacts/complete-multipart-upload":
+		s.serveCompleteMultipartUpload(w, body)
+		return
+	case pageSize > 0 && (req.Path == "experiments/search" || req.Path == "runs/search"):
+		if body, err = limitMaxResults(r, body, pageSize); err != nil {
+			writeError(w, http.StatusBadRequest, protos.ErrorCode_MALFORMED_REQUEST, err.Error())
+			return
+		}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	s.handler.ServeHTTP(w, r)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, code protos.ErrorCode, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"error_code": code.String(), "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, res interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// limitMaxResults sets max_results in the query or JSON body of r to at most pageSize.
+// Returns the new body, and updates the query of r in place.
+func limitMaxResults(r *http.Request, body []byte, pageSize int) ([]byte, error) {
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		if n, err := strconv.Atoi(query.Get("max_results")); err != nil || n > pageSize {
+			query.Set("max_results", strconv.Itoa(pageSize))
+		}
+		r.URL.RawQuery = query.Encode()
+		return body, nil
+	}
+	fields := make(map[string]interface{})
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, err
+		}
+	}
+	if n, ok := fields["max_results"].(float64); !ok || int(n) > pageSize {
+		fields["max_results"] = pageSize
+	}
+	return json.Marshal(fields)
+}
+
+// artifacts returns where the artifacts of the run are kept, or nil if there is no such run.
+func (s *store) artifacts(runID string) *artifactFiles {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if run, ok := s.runs[runID]; ok {
+		return run.artifacts
+	}
+	return nil
+}
+
+// serveCredentialsForWrite returns signed URIs that upload to serveUpload.
+func (s *Server) serveCredentialsForWrite(w http.ResponseWriter, body []byte) {
+	var req protos.GetCredentialsForWrite
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, protos.ErrorCode_MALFORMED_REQUEST, err.Error())
+		return
+	}
+	runID := req.GetRunId()
+	if s.Tracking.store.artifacts(runID) == nil {
+		writeError(w, http.StatusNotFound, protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no run with id "+runID)
+		return
+	}
+	res := &protos.GetCredentialsForWrite_Response{}
+	for _, p := range req.Path {
+		signedURI := s.URL + uploadPrefix + url.PathEscape(runID) + "/" + p
+		res.CredentialInfos = append(res.CredentialInfos, credentialInfo(runID, p, signedURI))
+	}
+	writeJSON(w, res)
+}
+
+// serveCredentialsForRead returns signed URIs that download from serveDownload.
+func (s *Server) serveCredentialsForRead(w http.ResponseWriter, body []byte) {
+	var req protos.GetCredentialsForRead
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, protos.ErrorCode_MALFORMED_REQUEST, err.Error())
+		return
+	}
+	runID := req.GetRunId()
+	if s.Tracking.store.artifacts(runID) == nil {
+		writeError(w, http.StatusNotFound, protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no run with id "+runID)
+		return
+	}
+	res := &protos.GetCredentialsForRead_Response{}
+	for _, p := range req.Path {
+		signedURI := s.URL + downloadPrefix + url.PathEscape(runID) + "/" + p
+		res.CredentialInfos = append(res.CredentialInfos, credentialInfo(runID, p, signedURI))
+	}
+	writeJSON(w, res)
+}
+
+// serveDownload writes the artifact at a signed URI, or 404 if there is none.
+func (s *Server) serveDownload(w http.ResponseWriter, r *http.Request) {
+	if !checkSignedRequest(w, r, http.MethodGet) {
+		return
+	}
+	runID, artifactPath, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, downloadPrefix), "/")
+	files := s.Tracking.store.artifacts(runID)
+	if files == nil {
+		http.Error(w, "no such run", http.StatusNotFound)
+		return
+	}
+	files.mtx.Lock()
+	contents, ok := files.files[artifactPath]
+	files.mtx.Unlock()
+	if !ok {
+		http.Error(w, "no such artifact", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(contents)))
+	w.Write(contents)
+}
+
+// credentialInfo returns a credential that requires the header that the upload endpoints check for.
+func credentialInfo(runID, path, signedURI string) *protos.ArtifactCredentialInfo {
+	credType := protos.ArtifactCredentialType_AZURE_SAS_URI
+	headerName, headerValue := uploadHeaderName, uploadHeaderValue
+	return &protos.ArtifactCredentialInfo{
+		RunId:     &runID,
+		Path:      &path,
+		SignedUri: &signedURI,
+		Headers:   []*protos.ArtifactCredentialInfo_HttpHeader{{Name: &headerName, Value: &headerValue}},
+		Type:      &credType,
+	}
+}
+
+// serveUpload stores the body of a PUT to a signed URI as an artifact.
+func (s *Server) serveUpload(w http.ResponseWriter, r *http.Request, body []byte) {
+	if !checkSignedRequest(w, r, http.MethodPut) {
+		return
+	}
+	runID, artifactPath, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, uploadPrefix), "/")
+	files := s.Tracking.store.artifacts(runID)
+	if !ok || artifactPath == "" || files == nil {
+		http.Error(w, "no such run or artifact path", http.StatusNotFound)
+		return
+	}
+	files.mtx.Lock()
+	defer files.mtx.Unlock()
+	files.files[artifactPath] = body
+}
+
+// checkSignedRequest checks the method and headers of a request to a signed URI,
+// and writes an error response if they are wrong.
+func checkSignedRequest(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if r.Header.Get(uploadHeaderName) != uploadHeaderValue {
+		http.Error(w, "missing "+uploadHeaderName+" header", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (s *Server) partCredentialInfo(uploadID string, upload *multipartUpload, partNumber int64) *protos.ArtifactCredentialInfo {
+	signedURI := s.URL + uploadPartPrefix + uploadID + "/" + strconv.FormatInt(partNumber, 10)
+	return credentialInfo(upload.runID, upload.path, signedURI)
+}
+
+func (s *Server) serveCreateMultipartUpload(w http.ResponseWriter, body []byte) {
+	var req protos.CreateMultipartUpload
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, protos.ErrorCode_MALFORMED_REQUEST, err.Error())
+		return
+	}
+	if s.Tracking.store.artifacts(req.GetRunId()) == nil {
+		writeError(w, http.StatusNotFound, protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no run with id "+req.GetRunId())
+		return
+	}
+	if req.GetPath() == "" || req.GetNumParts() < 1 {
+		writeError(w, http.StatusBadRequest, protos.ErrorCode_INVALID_PARAMETER_VALUE, "path and num_parts are required")
+		return
+	}
+	upload := &multipartUpload{
+		runID:    req.GetRunId(),
+		path:     req.GetPath(),
+		numParts: req.GetNumParts(),
+		parts:    make(map[int64][]byte),
+	}
+	s.mtx.Lock()
+	s.nextUploadID++
+	uploadID := strconv.Itoa(s.nextUploadID)
+	s.uploads[uploadID] = upload
+	s.mtx.Unlock()
+
+	res := &protos.CreateMultipartUpload_Response{
+		UploadId:            &uploadID,
+		AbortCredentialInfo: credentialInfo(upload.runID, upload.path, s.URL+uploadAbortPrefix+uploadID),
+	}
+	for partNumber := int64(1); partNumber <= upload.numParts; partNumber++ {
+		res.UploadCredentialInfos = append(res.UploadCredentialInfos, s.partCredentialInfo(uploadID, upload, partNumber))
+	}
+	writeJSON(w, res)
+}
+
+func (s *Server) serveGetPresignedUploadPartURL(w http.ResponseWriter, query url.Values) {
+	uploadID := query.Get("upload_id")
+	partNumber, err := strconv.ParseInt(query.Get("part_number"), 10, 64)
+	s.mtx.Lock()
+	upload, ok := s.uploads[uploadID]
+	s.mtx.Unlock()
+	if !ok || err != nil || partNumber < 1 || partNumber > upload.numParts {
+		writeError(w, http.StatusNotFound, protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no such upload or part")
+		return
+	}
+	writeJSON(w, &protos.GetPresignedUploadPartUrl_Response{
+		UploadCredentialInfo: s.partCredentialInfo(uploadID, upload, partNumber),
+	})
+}
+
+// serveUploadPart stores a part and returns its ETag, like AWS S3.
+func (s *Server) serveUploadPart(w http.ResponseWriter, r *http.Request, body []byte) {
+	if !checkSignedRequest(w, r, http.MethodPut) {
+		return
+	}
+	uploadID, partNumberStr, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, uploadPartPrefix), "/")
+	partNumber, err := strconv.ParseInt(partNumberStr, 10, 64)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	upload, ok := s.uploads[uploadID]
+	if !ok || err != nil || partNumber < 1 || partNumber > upload.numParts {
+		http.Error(w, "no such upload or part", http.StatusNotFound)
+		return
+	}
+	upload.parts[partNumber] = body
+	w.Header().Set("ETag", etag(body))
+}
+
+func etag(body []byte) string {
+	return fmt.Sprintf("%q", fmt.Sprintf("%x", md5.Sum(body)))
+}
+
+func (s *Server) serveCompleteMultipartUpload(w http.ResponseWriter, body []byte) {
+	var req protos.CompleteMultipartUpload
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, protos.ErrorCode_MALFORMED_REQUEST, err.Error())
+		return
+	}
+	s.mtx.Lock()
+	upload, ok := s.uploads[req.GetUploadId()]
+	if ok {
+		delete(s.uploads, req.GetUploadId())
+	}
+	s.mtx.Unlock()
+	if !ok || upload.runID != req.GetRunId() || upload.path != req.GetPath() {
+		writeError(w, http.StatusNotFound, protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no such upload")
+		return
+	}
+	if int64(len(req.PartEtags)) != upload.numParts {
+		writeError(w, http.StatusBadRequest, protos.ErrorCode_INVALID_PARAMETER_VALUE,
+			fmt.Sprintf("expected %d parts, got %d", upload.numParts, len(req.PartEtags)))
+		return
+	}
+	var contents []byte
+	for i, partEtag := range req.PartEtags {
+		part, ok := upload.parts[partEtag.GetPartNumber()]
+		if partEtag.GetPartNumber() != int64(i+1) || !ok || partEtag.GetEtag() != etag(part) {
+			writeError(w, http.StatusBadRequest, protos.ErrorCode_INVALID_PARAMETER_VALUE,
+				fmt.Sprintf("part %d is missing or has the wrong ETag", i+1))
+			return
+		}
+		contents = append(contents, part...)
+	}
+	files := s.Tracking.store.artifacts(upload.runID)
+	files.mtx.Lock()
+	files.files[upload.path] = contents
+	files.mtx.Unlock()
+	writeJSON(w, &protos.CompleteMultipartUpload_Response{})
+}
+
+func (s *Server) serveAbortUpload(w http.ResponseWriter, r *http.Request) {
+	if !checkSignedRequest(w, r, http.MethodDelete) {
+		return
+	}
+	uploadID := strings.TrimPrefix(r.URL.Path, uploadAbortPrefix)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.uploads[uploadID]; !ok {
+		http.Error(w, "no such upload", http.StatusNotFound)
+		return
+	}
+	delete(s.uploads, uploadID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,287 @@
+// Package mlflowtest helps test code that logs to MLFlow.
+//
+// [NewTracking] creates an in-memory implementation of [mlflow.Tracking], which can be passed to
+// the code under test instead of a client for a real tracking server. The Assert functions
+// check what was logged, and work with any [mlflow.Run], including ones from a real tracking server.
+package mlflowtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+const (
+	defaultExperimentID   = "0"
+	defaultExperimentName = "Default"
+)
+
+// store holds everything logged to a Tracking and the copies made by WithContext.
+type store struct {
+	mtx          sync.Mutex
+	now          func() time.Time
+	artifactRoot string
+	experiments  map[string]*experimentData
+	runs         map[string]*runData
+	// Used to create IDs, which are sequential so that tests are deterministic.
+	nextExperimentID int
+	nextRunID        int
+}
+
+type experimentData struct {
+	id             string
+	name           string
+	lifecycleStage string
+	creationTime   time.Time
+	lastUpdateTime time.Time
+	tags           map[string]string
+}
+
+// Tracking is an in-memory implementation of [mlflow.Tracking].
+// It is safe for concurrent use, as are the experiments and runs obtained from it.
+type Tracking struct {
+	store *store
+	ctx   context.Context
+}
+
+// Option configures a [Tracking] created by [NewTracking].
+type Option func(*store)
+
+// WithClock sets the function used to get the current time, e.g. for start times and the
+// timestamps of metrics. Defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *store) {
+		s.now = now
+	}
+}
+
+// WithArtifactRoot sets the URI under which artifact locations are made up, as
+// root/<experiment ID>/<run ID>/artifacts. Artifacts are kept in memory whatever the URI is.
+// Defaults to "memory://".
+func WithArtifactRoot(root string) Option {
+	return func(s *store) {
+		if !strings.HasSuffix(root, "/") {
+			root += "/"
+		}
+		s.artifactRoot = root
+	}
+}
+
+// NewTracking creates an empty in-memory store, apart from the default experiment,
+// like a new tracking server.
+func NewTracking(opts ...Option) *Tracking {
+	s := &store{
+		now:          time.Now,
+		artifactRoot: "memory://",
+		experiments:  make(map[string]*experimentData),
+		runs:         make(map[string]*runData),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	now := s.now()
+	s.experiments[defaultExperimentID] = &experimentData{
+		id:             defaultExperimentID,
+		name:           defaultExperimentName,
+		lifecycleStage: mlflow.LifecycleStageActive,
+		creationTime:   now,
+		lastUpdateTime: now,
+		tags:           make(map[string]string),
+	}
+	s.nextExperimentID = 1
+	return &Tracking{store: s, ctx: context.Background()}
+}
+
+func newError(code protos.ErrorCode, format string, args ...interface{}) error {
+	return &mlflow.Error{Code: code.String(), Message: fmt.Sprintf(format, args...)}
+}
+
+// lock locks the store, unless ctx is done.
+func (s *store) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	return nil
+}
+
+// Implements [mlflow.Tracking.WithContext].
+// Operations fail with ctx.Err() once ctx is done.
+func (t *Tracking) WithContext(ctx context.Context) mlflow.Tracking {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *t
+	c.ctx = ctx
+	return &c
+}
+
+func (t *Tracking) URI() string {
+	return "memory://"
+}
+
+func (t *Tracking) UIURL() string {
+	return ""
+}
+
+func (t *Tracking) experiment(id string) *Experiment {
+	return &Experiment{store: t.store, ctx: t.ctx, id: id}
+}
+
+func (t *Tracking) ExperimentsByName(opts ...mlflow.SearchOption) (map[string]mlflow.Experiment, error) {
+	_, viewType := mlflow.SearchOptionValues(opts...)
+	if err := t.store.lock(t.ctx); err != nil {
+		return nil, err
+	}
+	defer t.store.mtx.Unlock()
+	experiments := make(map[string]mlflow.Experiment)
+	for _, exp := range t.store.experiments {
+		if viewTypeIncludes(viewType, exp.lifecycleStage) {
+			experiments[exp.name] = t.experiment(exp.id)
+		}
+	}
+	return experiments, nil
+}
+
+func viewTypeIncludes(viewType mlflow.ViewType, lifecycleStage string) bool {
+	switch viewType {
+	case mlflow.ViewTypeDeletedOnly:
+		return lifecycleStage == mlflow.LifecycleStageDeleted
+	case mlflow.ViewTypeAll:
+		return true
+	}
+	return lifecycleStage != mlflow.LifecycleStageDeleted
+}
+
+// createExperiment must be called with the store locked.
+func (s *store) createExperiment(name string) (*experimentData, error) {
+	if name == "" {
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "experiment name must not be empty")
+	}
+	// Includes deleted experiments, since their names can't be reused.
+	for _, exp := range s.experiments {
+		if exp.name == name {
+			return nil, newError(protos.ErrorCode_RESOURCE_ALREADY_EXISTS, "experiment with name %q already exists", name)
+		}
+	}
+	now := s.now()
+	exp := &experimentData{
+		id:             strconv.Itoa(s.nextExperimentID),
+		name:           name,
+		lifecycleStage: mlflow.LifecycleStageActive,
+		creationTime:   now,
+		lastUpdateTime: now,
+		tags:           make(map[string]string),
+	}
+	s.nextExperimentID++
+	s.experiments[exp.id] = exp
+	return exp, nil
+}
+
+func (t *Tracking) CreateExperiment(name string) (mlflow.Experiment, error) {
+	if err := t.store.lock(t.ctx); err != nil {
+		return nil, err
+	}
+	defer t.store.mtx.Unlock()
+	exp, err := t.store.createExperiment(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.experiment(exp.id), nil
+}
+
+func (t *Tracking) GetOrCreateExperimentWithName(name string) (mlflow.Experiment, error) {
+	if name == "" {
+		name = defaultExperimentName
+	}
+	if err := t.store.lock(t.ctx); err != nil {
+		return nil, err
+	}
+	defer t.store.mtx.Unlock()
+	for _, exp := range t.store.experiments {
+		if exp.name != name {
+			continue
+		}
+		if exp.lifecycleStage == mlflow.LifecycleStageDeleted {
+			return nil, newError(protos.ErrorCode_RESOURCE_ALREADY_EXISTS, "experiment %q is deleted. Restore it or choose a different name", name)
+		}
+		return t.experiment(exp.id), nil
+	}
+	exp, err := t.store.createExperiment(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.experiment(exp.id), nil
+}
+
+func (t *Tracking) GetExperiment(id string) (mlflow.Experiment, error) {
+	if id == "" {
+		id = defaultExperimentID
+	}
+	if err := t.store.lock(t.ctx); err != nil {
+		return nil, err
+	}
+	defer t.store.mtx.Unlock()
+	if _, ok := t.store.experiments[id]; !ok {
+		return nil, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no experiment with id %s", id)
+	}
+	return t.experiment(id), nil
+}
+
+// Implements [mlflow.Tracking.GetRun].
+func (t *Tracking) GetRun(runID string) (mlflow.Run, error) {
+	if err := t.store.lock(t.ctx); err != nil {
+		return nil, err
+	}
+	defer t.store.mtx.Unlock()
+	if _, ok := t.store.runs[runID]; !ok {
+		return nil, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no run with id %s", runID)
+	}
+	return t.run(runID), nil
+}
+
+func (t *Tracking) run(id string) *Run {
+	return &Run{store: t.store, ctx: t.ctx, id: id}
+}
+
+func (t *Tracking) SearchRuns(experimentIDs []string, filter string, orderBy []string, pageToken string, opts ...mlflow.SearchOption) ([]mlflow.Run, string, error) {
+	if err := t.store.lock(t.ctx); err != nil {
+		return nil, "", err
+	}
+	var runs []mlflow.Run
+	for _, id := range experimentIDs {
+		if _, ok := t.store.experiments[id]; !ok {
+			t.store.mtx.Unlock()
+			return nil, "", newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no experiment with id %s", id)
+		}
+	}
+	for _, id := range experimentIDs {
+		for _, run := range t.store.runs {
+			if run.info.ExperimentID == id {
+				runs = append(runs, t.run(run.info.ID))
+			}
+		}
+	}
+	t.store.mtx.Unlock()
+	// FilterRuns reads each run, which locks the store.
+	return mlflow.FilterRuns(runs, filter, orderBy, pageToken, opts...)
+}
+
+// Runs returns all runs in the store, including deleted ones, in the order they were created.
+func (t *Tracking) Runs() []*Run {
+	t.store.mtx.Lock()
+	defer t.store.mtx.Unlock()
+	runs := make([]*Run, 0, len(t.store.runs))
+	for id := range t.store.runs {
+		runs = append(runs, t.run(id))
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].id < runs[j].id })
+	return runs
+}
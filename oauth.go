@@ -0,0 +1,100 @@
+package mlflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Tokens are refreshed this long before they expire, so that they don't expire in flight.
+	oauthExpiryMargin = time.Minute
+	// The lifetime assumed for tokens whose response has no expires_in, which is optional.
+	// It is the lifetime of Databricks tokens.
+	oauthDefaultLifetime = time.Hour
+)
+
+// oauthTokenSource gets access tokens using the OAuth 2.0 client credentials grant,
+// also known as machine-to-machine (M2M) auth, and caches them until shortly before they expire.
+// It is safe for concurrent use.
+type oauthTokenSource struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	mtx    sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// WithOAuthClientCredentials authenticates to the tracking server with access tokens obtained
+// from tokenURL using the OAuth client credentials grant. Tokens are refreshed automatically
+// before they expire. It takes precedence over the bearer token and [WithBasicAuth].
+func WithOAuthClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) RESTStoreOption {
+	return func(rs *RESTStore) {
+		rs.oauth = &oauthTokenSource{
+			tokenURL:     tokenURL,
+			clientID:     clientID,
+			clientSecret: clientSecret,
+			scopes:       scopes,
+		}
+	}
+}
+
+// Token returns a valid access token, fetching a new one with client if needed.
+func (ts *oauthTokenSource) Token(ctx context.Context, client *http.Client) (string, error) {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+	if ts.token != "" && time.Now().Add(oauthExpiryMargin).Before(ts.expiry) {
+		return ts.token, nil
+	}
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(ts.scopes) > 0 {
+		form.Set("scope", strings.Join(ts.scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(ts.clientID), url.QueryEscape(ts.clientSecret))
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get OAuth token from %s: %w", ts.tokenURL, err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read OAuth token response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get OAuth token from %s with status %s: %s", ts.tokenURL, res.Status, body)
+	}
+	var parsed struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse OAuth token response: %w", err)
+	}
+	if parsed.AccessToken == "" {
+		return "", fmt.Errorf("OAuth token response from %s has no access_token", ts.tokenURL)
+	}
+	lifetime := time.Duration(parsed.ExpiresIn) * time.Second
+	if parsed.ExpiresIn <= 0 {
+		lifetime = oauthDefaultLifetime
+	}
+	ts.token = parsed.AccessToken
+	ts.expiry = time.Now().Add(lifetime)
+	return ts.token, nil
+}
@@ -0,0 +1,154 @@
+package mlflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// retryPolicy decides whether and when a failed HTTP request is retried.
+// Based on the Python client:
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/utils/request_utils.py
+type retryPolicy struct {
+	maxRetries    int
+	backoffFactor time.Duration
+}
+
+const maxRetryBackoff = 2 * time.Minute
+
+var defaultRetryPolicy = retryPolicy{maxRetries: 7, backoffFactor: 2 * time.Second}
+
+// Statuses that indicate the request may succeed if retried.
+var retryableStatuses = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// The global math/rand source is not seeded before Go 1.20, and rand.Rand is not safe
+// for concurrent use, so jitter uses its own locked source.
+var (
+	jitterMtx  sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// shouldRetry returns true if a request with the given method that got res or err is worth retrying.
+func (p retryPolicy) shouldRetry(method string, res *http.Response, err error) bool {
+	if err != nil {
+		// The server may have processed the request, so only retry if that's harmless.
+		return method == http.MethodGet || method == http.MethodHead || method == http.MethodPut
+	}
+	return retryableStatuses[res.StatusCode]
+}
+
+// delay returns how long to wait before the given retry, starting from 1.
+// A Retry-After from the server is honoured up to maxRetryBackoff.
+func (p retryPolicy) delay(retry int, res *http.Response) time.Duration {
+	if wait, ok := retryAfter(res); ok {
+		if wait > maxRetryBackoff {
+			return maxRetryBackoff
+		}
+		return wait
+	}
+	backoff := p.backoffFactor
+	for i := 1; i < retry && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	// Wait between half and all of the backoff so that clients that failed together don't retry together.
+	jitterMtx.Lock()
+	defer jitterMtx.Unlock()
+	return backoff/2 + time.Duration(jitterRand.Int63n(int64(backoff/2)+1))
+}
+
+// retryAfter parses the Retry-After header, which is either a number of seconds or a date.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+	header := res.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// send sends the request returned by newRequest with client, retrying according to rs.retry.
+// newRequest is called for each attempt so that the request body can be re-read.
+// Returns the last response, with its body already read and closed.
+// Error statuses are not errors, so callers must check the response status.
+func (rs *RESTStore) send(client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, []byte, error) {
+	return rs.sendRetrying(client, newRequest, false)
+}
+
+// sendForBody is like send, but if the response status is 200 OK it is returned with its body unread,
+// so that large downloads can be streamed. Failures while reading the body are not retried.
+// The caller must close the body of an OK response.
+func (rs *RESTStore) sendForBody(client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, []byte, error) {
+	return rs.sendRetrying(client, newRequest, true)
+}
+
+func (rs *RESTStore) sendRetrying(client *http.Client, newRequest func() (*http.Request, error), streamOK bool) (*http.Response, []byte, error) {
+	for retry := 1; ; retry++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, nil, err
+		}
+		var body []byte
+		res, err := client.Do(req)
+		if err != nil && rs.ctx.Err() != nil {
+			// So that it can be told apart from a client timeout, which also matches context.DeadlineExceeded.
+			return nil, nil, rs.ctx.Err()
+		}
+		if err == nil {
+			if streamOK && res.StatusCode == http.StatusOK {
+				return res, nil, nil
+			}
+			body, err = io.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				err = fmt.Errorf("failed to read response body: %w", err)
+			}
+		}
+		if retry > rs.retry.maxRetries || rs.ctx.Err() != nil || !rs.retry.shouldRetry(req.Method, res, err) {
+			return res, body, err
+		}
+		if err := sleepContext(rs.ctx, rs.retry.delay(retry, res)); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// sleepContext waits for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
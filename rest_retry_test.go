@@ -0,0 +1,117 @@
+package mlflow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRESTStoreRetries(t *testing.T) {
+	var attempts int32
+	var failures int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= atomic.LoadInt32(&failures) {
+			if r.URL.Query().Get("experiment_id") == "reset" || r.URL.Path == "/api/2.0/mlflow/experiments/create" {
+				// Close the connection without responding.
+				if conn, _, err := w.(http.Hijacker).Hijack(); assert.NoError(t, err) {
+					conn.Close()
+				}
+				return
+			}
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"experiment_id": "1", "experiment": {"experiment_id": "1", "name": "exp"}}`))
+	}))
+	defer server.Close()
+	store, err := NewRESTStore(server.URL, "", WithMaxRetries(2), WithBackoffFactor(0))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name         string
+		failures     int32
+		call         func() error
+		wantErr      bool
+		wantAttempts int32
+	}{
+		{"status", 2, func() error { _, err := store.GetExperiment("1"); return err }, false, 3},
+		{"status exhausted", 3, func() error { _, err := store.GetExperiment("1"); return err }, true, 3},
+		{"reset GET", 1, func() error { _, err := store.GetExperiment("reset"); return err }, false, 2},
+		{"reset POST", 1, func() error { _, err := store.CreateExperiment("exp"); return err }, true, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&attempts, 0)
+			atomic.StoreInt32(&failures, tc.failures)
+			err := tc.call()
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantAttempts, atomic.LoadInt32(&attempts))
+		})
+	}
+}
+
+func TestRESTStoreRetryAfterContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	store, err := NewRESTStore(server.URL, "", WithMaxRetries(1))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = store.WithContext(ctx).GetExperiment("1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := retryPolicy{maxRetries: 10, backoffFactor: time.Second}
+	for _, tc := range []struct {
+		retry int
+		max   time.Duration
+	}{
+		{1, time.Second},
+		{3, 4 * time.Second},
+		{50, maxRetryBackoff},
+	} {
+		delay := policy.delay(tc.retry, nil)
+		assert.GreaterOrEqual(t, delay, tc.max/2)
+		assert.LessOrEqual(t, delay, tc.max)
+	}
+
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("Retry-After", "5")
+	assert.Equal(t, 5*time.Second, policy.delay(1, res))
+	res.Header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	assert.Equal(t, time.Duration(0), policy.delay(1, res))
+	res.Header.Set("Retry-After", "86400")
+	assert.Equal(t, maxRetryBackoff, policy.delay(1, res))
+	res.Header.Set("Retry-After", time.Now().Add(48*time.Hour).UTC().Format(http.TimeFormat))
+	assert.Equal(t, maxRetryBackoff, policy.delay(1, res))
+}
+
+func TestRESTStoreOptionsFromConfig(t *testing.T) {
+	config := map[string]string{MaxRetriesEnvName: "3", BackoffFactorEnvName: "0.5"}
+	opts, err := restStoreOptionsFromConfig(func(key string) string { return config[key] })
+	require.NoError(t, err)
+	store, err := NewRESTStore("http://localhost", "", opts...)
+	require.NoError(t, err)
+	assert.Equal(t, retryPolicy{maxRetries: 3, backoffFactor: 500 * time.Millisecond}, store.(*RESTStore).retry)
+
+	config[MaxRetriesEnvName] = "many"
+	_, err = restStoreOptionsFromConfig(func(key string) string { return config[key] })
+	assert.Error(t, err)
+}
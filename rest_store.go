@@ -2,6 +2,9 @@ package mlflow
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,11 +13,15 @@ import (
 	"os"
 	"os/user"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Astera-org/mlflow-go/protos"
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 // Implements Tracking interface
@@ -23,13 +30,216 @@ import (
 type RESTStore struct {
 	baseURL     string
 	bearerToken string
+	username    string
+	password    string
+	oauth       *oauthTokenSource
+	ctx         context.Context
+	retry       retryPolicy
+	client      *http.Client
+	// client with middleware applied, for requests to the tracking server.
+	trackingClient *http.Client
+	// How many files DBFSArtifactRepo.LogArtifacts uploads at once.
+	uploadWorkers int
+	// Applied to client by NewRESTStore, so the options can be given in any order.
+	timeout    time.Duration
+	tlsConfig  *tls.Config
+	middleware []Middleware
 }
 
-func NewRESTStore(baseURL, bearerToken string) (Tracking, error) {
+// defaultArtifactUploadWorkers is the default for [WithArtifactUploadWorkers].
+const defaultArtifactUploadWorkers = 8
+
+// RESTStoreOption configures a [RESTStore] created by [NewRESTStore].
+type RESTStoreOption func(*RESTStore)
+
+// WithMaxRetries sets how many times a failed request is retried.
+// See [RESTStore] for which failures are retried. Defaults to 7. Zero disables retries.
+func WithMaxRetries(n int) RESTStoreOption {
+	return func(rs *RESTStore) {
+		rs.retry.maxRetries = n
+	}
+}
+
+// WithBackoffFactor sets the base delay between retries.
+// The nth retry waits about factor * 2^(n-1), up to 2 minutes. Defaults to 2 seconds.
+func WithBackoffFactor(factor time.Duration) RESTStoreOption {
+	return func(rs *RESTStore) {
+		rs.retry.backoffFactor = factor
+	}
+}
+
+// WithArtifactUploadWorkers sets how many files are uploaded at once when logging a directory
+// of artifacts to DBFS. Defaults to 8.
+func WithArtifactUploadWorkers(n int) RESTStoreOption {
+	return func(rs *RESTStore) {
+		rs.uploadWorkers = n
+	}
+}
+
+// WithHTTPClient sets the client used for all requests, including artifact uploads.
+// Defaults to [http.DefaultClient].
+func WithHTTPClient(client *http.Client) RESTStoreOption {
+	return func(rs *RESTStore) {
+		rs.client = client
+	}
+}
+
+// WithTimeout sets the time limit for each HTTP request, including reading the response body.
+// Retries are not counted against it. Defaults to no limit.
+func WithTimeout(timeout time.Duration) RESTStoreOption {
+	return func(rs *RESTStore) {
+		rs.timeout = timeout
+	}
+}
+
+// WithTLSConfig sets the TLS configuration, e.g. to trust a custom CA or present a client certificate.
+// If used with [WithHTTPClient], the client's Transport must be nil or an *http.Transport.
+func WithTLSConfig(config *tls.Config) RESTStoreOption {
+	return func(rs *RESTStore) {
+		rs.tlsConfig = config
+	}
+}
+
+// WithBasicAuth authenticates to the tracking server with HTTP basic auth,
+// e.g. for a server started with `mlflow server --app-name basic-auth`.
+// It takes precedence over the bearer token.
+func WithBasicAuth(username, password string) RESTStoreOption {
+	return func(rs *RESTStore) {
+		rs.username = username
+		rs.password = password
+	}
+}
+
+// NewRESTStore creates a client for the tracking server at baseURL.
+//
+// Requests that fail with HTTP status 408, 429, 500, 502, 503 or 504 are retried with exponential backoff,
+// honoring the Retry-After header if the server sends one. Requests that fail to get any response,
+// e.g. because the connection was reset, are only retried if they are idempotent (GET or PUT),
+// since the server may have already processed them.
+func NewRESTStore(baseURL, bearerToken string, opts ...RESTStoreOption) (Tracking, error) {
 	if baseURL[len(baseURL)-1] == '/' {
 		baseURL = baseURL[:len(baseURL)-1]
 	}
-	return &RESTStore{baseURL: baseURL, bearerToken: bearerToken}, nil
+	rs := &RESTStore{
+		baseURL:       baseURL,
+		bearerToken:   bearerToken,
+		ctx:           context.Background(),
+		retry:         defaultRetryPolicy,
+		client:        http.DefaultClient,
+		uploadWorkers: defaultArtifactUploadWorkers,
+	}
+	for _, opt := range opts {
+		opt(rs)
+	}
+	if rs.timeout != 0 || rs.tlsConfig != nil {
+		// Copy so that the caller's client is not modified.
+		client := *rs.client
+		if rs.timeout != 0 {
+			client.Timeout = rs.timeout
+		}
+		if rs.tlsConfig != nil {
+			var transport *http.Transport
+			switch t := client.Transport.(type) {
+			case nil:
+				transport = http.DefaultTransport.(*http.Transport).Clone()
+			case *http.Transport:
+				transport = t.Clone()
+			default:
+				return nil, fmt.Errorf("mlflow.NewRESTStore: cannot set TLS config on HTTP client transport of type %T", t)
+			}
+			transport.TLSClientConfig = rs.tlsConfig
+			client.Transport = transport
+		}
+		rs.client = &client
+	}
+	rs.trackingClient = rs.client
+	if len(rs.middleware) > 0 {
+		rs.trackingClient = withMiddleware(rs.client, rs.middleware)
+	}
+	return rs, nil
+}
+
+// restStoreOptionsFromConfig reads the optional RESTStore settings, e.g. [MaxRetriesEnvName].
+func restStoreOptionsFromConfig(getConfig func(string) string) ([]RESTStoreOption, error) {
+	opts := make([]RESTStoreOption, 0)
+	if username := getConfig(UsernameEnvName); username != "" {
+		opts = append(opts, WithBasicAuth(username, getConfig(PasswordEnvName)))
+	}
+	if val := getConfig(MaxRetriesEnvName); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s %q, expected a non-negative integer", MaxRetriesEnvName, val)
+		}
+		opts = append(opts, WithMaxRetries(n))
+	}
+	if val := getConfig(BackoffFactorEnvName); val != "" {
+		seconds, err := strconv.ParseFloat(val, 64)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid %s %q, expected a non-negative number of seconds", BackoffFactorEnvName, val)
+		}
+		opts = append(opts, WithBackoffFactor(time.Duration(seconds*float64(time.Second))))
+	}
+	if val := getConfig(TimeoutEnvName); val != "" {
+		seconds, err := strconv.ParseFloat(val, 64)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid %s %q, expected a non-negative number of seconds", TimeoutEnvName, val)
+		}
+		opts = append(opts, WithTimeout(time.Duration(seconds*float64(time.Second))))
+	}
+	tlsConfig, err := tlsConfigFromConfig(getConfig)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts = append(opts, WithTLSConfig(tlsConfig))
+	}
+	return opts, nil
+}
+
+// tlsConfigFromConfig returns the TLS config described by [InsecureTLSEnvName], [ServerCertPathEnvName]
+// and [ClientCertPathEnvName], or nil if none of them are set.
+// Based on
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/utils/rest_utils.py
+func tlsConfigFromConfig(getConfig func(string) string) (*tls.Config, error) {
+	insecure := false
+	if val := getConfig(InsecureTLSEnvName); val != "" {
+		var err error
+		if insecure, err = strconv.ParseBool(val); err != nil {
+			return nil, fmt.Errorf("invalid %s %q, expected true or false", InsecureTLSEnvName, val)
+		}
+	}
+	serverCertPath := getConfig(ServerCertPathEnvName)
+	clientCertPath := getConfig(ClientCertPathEnvName)
+	if !insecure && serverCertPath == "" && clientCertPath == "" {
+		return nil, nil
+	}
+	if insecure && serverCertPath != "" {
+		return nil, fmt.Errorf("%s and %s cannot both be set", InsecureTLSEnvName, ServerCertPathEnvName)
+	}
+	config := &tls.Config{InsecureSkipVerify: insecure}
+	if serverCertPath != "" {
+		pem, err := os.ReadFile(serverCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", ServerCertPathEnvName, err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s %q", ServerCertPathEnvName, serverCertPath)
+		}
+	}
+	if clientCertPath != "" {
+		// Like the Python client, the file must contain both the certificate and the private key.
+		pem, err := os.ReadFile(clientCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", ClientCertPathEnvName, err)
+		}
+		cert, err := tls.X509KeyPair(pem, pem)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", ClientCertPathEnvName, clientCertPath, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
 }
 
 func (rs *RESTStore) do(method, path string, req, res interface{}) error {
@@ -37,46 +247,80 @@ func (rs *RESTStore) do(method, path string, req, res interface{}) error {
 		return fmt.Errorf("GET requests cannot have a body")
 	}
 	url := rs.baseURL + "/api/2.0/mlflow/" + path
-	var reqBody io.Reader
+	var reqJSON []byte
 	if req != nil {
-		reqJSON, err := json.Marshal(req)
-		if err != nil {
+		var err error
+		if reqJSON, err = json.Marshal(req); err != nil {
 			return fmt.Errorf("failed to marshall request to JSON: %v", err)
 		}
-		reqBody = bytes.NewReader(reqJSON)
 	}
 
-	httpReq, err := http.NewRequest(method, url, reqBody)
+	httpRes, resBody, err := rs.send(rs.trackingClient, func() (*http.Request, error) {
+		var reqBody io.Reader
+		if reqJSON != nil {
+			reqBody = bytes.NewReader(reqJSON)
+		}
+		httpReq, err := http.NewRequestWithContext(rs.ctx, method, url, reqBody)
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if err := rs.authorize(httpReq); err != nil {
+			return nil, err
+		}
+		return httpReq, nil
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to %s: %w", method, err)
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	if rs.bearerToken != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+rs.bearerToken)
+	if httpRes.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s failed with status %s: %w", method, url, httpRes.Status,
+			errorFromResponse(httpRes.StatusCode, resBody))
 	}
-
-	httpRes, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to %s: %v", method, err)
+	// Responses are protobuf JSON, e.g. 64-bit integers may be strings and NaN is "NaN".
+	if msg, ok := res.(proto.Message); ok {
+		err = protojson.UnmarshalOptions{AllowPartial: true, DiscardUnknown: true}.Unmarshal(resBody, msg)
+	} else {
+		err = json.Unmarshal(resBody, res)
 	}
-	defer httpRes.Body.Close()
-	resBody, err := io.ReadAll(httpRes.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %v", err)
-	}
-	if httpRes.StatusCode != http.StatusOK {
-		return fmt.Errorf("%s %s failed with status %s: %s", method, url, httpRes.Status, resBody)
-	}
-	if err = json.Unmarshal(resBody, res); err != nil {
 		return fmt.Errorf("failed to unmarshall response body: %s\n%v", resBody, err)
 	}
 	return nil
 }
 
+// authorize adds the credentials for the tracking server to req.
+func (rs *RESTStore) authorize(req *http.Request) error {
+	switch {
+	case rs.oauth != nil:
+		token, err := rs.oauth.Token(req.Context(), rs.client)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case rs.username != "":
+		req.SetBasicAuth(rs.username, rs.password)
+	case rs.bearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+rs.bearerToken)
+	}
+	return nil
+}
+
 func (rs *RESTStore) URI() string {
 	return rs.baseURL
 }
 
+// Implements [Tracking.WithContext].
+// Cancelling ctx aborts in-flight HTTP requests.
+func (rs *RESTStore) WithContext(ctx context.Context) Tracking {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *rs
+	c.ctx = ctx
+	return &c
+}
+
 func (rs *RESTStore) UIURL() string {
 	middle := ""
 	if strings.Contains(rs.baseURL, "databricks.com") {
@@ -94,31 +338,37 @@ func (rs *RESTStore) CreateExperiment(name string) (Experiment, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &restExperiment{rs, *resp.ExperimentId}, nil
+	return &restExperiment{store: rs, id: *resp.ExperimentId, name: name}, nil
 }
 
-func (rs *RESTStore) ExperimentsByName() (map[string]Experiment, error) {
-	var resp protos.SearchExperiments_Response
+func (rs *RESTStore) ExperimentsByName(opts ...SearchOption) (map[string]Experiment, error) {
+	options := newSearchOptions(opts)
 	maxResults := int64(1000)
+	viewType := protos.ViewType(options.viewType)
 	experiments := make(map[string]Experiment)
 	path := "experiments/search"
+	var pageToken *string
 	for {
+		// A new response for each page, since unmarshalling leaves fields that are absent unchanged.
+		var resp protos.SearchExperiments_Response
 		err := rs.do(http.MethodPost,
 			path,
 			protos.SearchExperiments{
 				MaxResults: &maxResults,
-				PageToken:  resp.NextPageToken,
+				PageToken:  pageToken,
+				ViewType:   &viewType,
 			},
 			&resp)
 		if err != nil {
 			return nil, err
 		}
 		for _, exp := range resp.Experiments {
-			experiments[*exp.Name] = &restExperiment{rs, *exp.ExperimentId}
+			experiments[*exp.Name] = &restExperiment{store: rs, id: *exp.ExperimentId, name: *exp.Name}
 		}
-		if resp.NextPageToken == nil {
+		if resp.GetNextPageToken() == "" {
 			break
 		}
+		pageToken = resp.NextPageToken
 	}
 	return experiments, nil
 }
@@ -146,15 +396,20 @@ func (rs *RESTStore) GetExperiment(id string) (Experiment, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &restExperiment{rs, *resp.Experiment.ExperimentId}, nil
+	return &restExperiment{store: rs, id: *resp.Experiment.ExperimentId, name: resp.Experiment.GetName()}, nil
 }
 
-func (rs *RESTStore) SearchRuns(experimentIDs []string, filter string, orderBy []string, pageToken string) ([]Run, string, error) {
+func (rs *RESTStore) SearchRuns(experimentIDs []string, filter string, orderBy []string, pageToken string, opts ...SearchOption) ([]Run, string, error) {
+	options := newSearchOptions(opts)
 	var resp protos.SearchRuns_Response
+	maxResults := int32(options.maxResults)
+	viewType := protos.ViewType(options.viewType)
 	req := protos.SearchRuns{
 		ExperimentIds: experimentIDs,
 		Filter:        &filter,
 		OrderBy:       orderBy,
+		MaxResults:    &maxResults,
+		RunViewType:   &viewType,
 	}
 	if pageToken != "" {
 		req.PageToken = &pageToken
@@ -173,36 +428,54 @@ func (rs *RESTStore) SearchRuns(experimentIDs []string, filter string, orderBy [
 	return runs, nextPageToken, nil
 }
 
+// Implements [Tracking.GetRun].
+func (rs *RESTStore) GetRun(runID string) (Run, error) {
+	var resp protos.GetRun_Response
+	if err := rs.do(http.MethodGet, "runs/get?run_id="+url.QueryEscape(runID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &restRun{resp.Run.Info, resp.Run.Data, rs}, nil
+}
+
 // Implements Experiment interface
 type restExperiment struct {
 	store *RESTStore
 	id    string
+	name  string
 }
 
-func (exp *restExperiment) CreateRun(name string) (Run, error) {
+func (exp *restExperiment) CreateRun(name string, opts ...CreateRunOption) (Run, error) {
+	options := newCreateRunOptions(opts)
 	if name == "" {
 		// This differs from Python client which generates a random adjective-noun-number.
 		uuid := strings.ReplaceAll(uuid.NewString(), "-", "")
 		name = uuid[0:8]
 	}
 	var resp protos.CreateRun_Response
-	startTime := time.Now().UnixMilli()
-	userKey := UserTagKey
+	startTime := options.startTime.UnixMilli()
 	userName := ""
 	user, err := user.Current()
 	if err == nil {
 		userName = user.Username
 	}
+	userKey := UserTagKey
+	// Unfortunately Databricks ignores this tag.
+	tags := []*protos.RunTag{{Key: &userKey, Value: &userName}}
+	for _, tag := range options.tags {
+		tag := tag
+		if tag.Key == UserTagKey {
+			tags[0].Value = &tag.Val
+			continue
+		}
+		tags = append(tags, &protos.RunTag{Key: &tag.Key, Value: &tag.Val})
+	}
 	err = exp.store.do(http.MethodPost,
 		"runs/create",
 		protos.CreateRun{
 			ExperimentId: &exp.id,
 			RunName:      &name,
 			StartTime:    &startTime,
-			Tags: []*protos.RunTag{
-				// Unfortunately Databricks ignores this tag.
-				{Key: &userKey, Value: &userName},
-			},
+			Tags:         tags,
 		},
 		&resp)
 	if err != nil {
@@ -220,10 +493,116 @@ func (exp *restExperiment) GetRun(runID string) (Run, error) {
 	return &restRun{resp.Run.Info, resp.Run.Data, exp.store}, nil
 }
 
+// Implements [Experiment.WithContext].
+func (exp *restExperiment) WithContext(ctx context.Context) Experiment {
+	c := *exp
+	c.store = exp.store.WithContext(ctx).(*RESTStore)
+	return &c
+}
+
 func (exp *restExperiment) ID() string {
 	return exp.id
 }
 
+func (exp *restExperiment) Name() string {
+	return exp.name
+}
+
+// Implements [Experiment.SetName].
+func (exp *restExperiment) SetName(name string) error {
+	var resp protos.UpdateExperiment_Response
+	if err := exp.store.do(http.MethodPost,
+		"experiments/update",
+		protos.UpdateExperiment{ExperimentId: &exp.id, NewName: &name},
+		&resp); err != nil {
+		return err
+	}
+	exp.name = name
+	return nil
+}
+
+// Implements [Experiment.SetTag].
+func (exp *restExperiment) SetTag(key, value string) error {
+	var resp protos.SetExperimentTag_Response
+	return exp.store.do(http.MethodPost,
+		"experiments/set-experiment-tag",
+		protos.SetExperimentTag{ExperimentId: &exp.id, Key: &key, Value: &value},
+		&resp)
+}
+
+// Implements [Experiment.GetTag].
+func (exp *restExperiment) GetTag(key string) (string, error) {
+	info, err := exp.Info()
+	if err != nil {
+		return "", err
+	}
+	for _, tag := range info.Tags {
+		if tag.Key == key {
+			return tag.Val, nil
+		}
+	}
+	return "", newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no tag with key %s", key)
+}
+
+// deleteExperimentTag is not in protos/service.proto because the RPC was added
+// to MLFlow after the protos were copied.
+type deleteExperimentTag struct {
+	ExperimentID string `json:"experiment_id"`
+	Key          string `json:"key"`
+}
+
+// Implements [Experiment.DeleteTag].
+// Older tracking servers don't support this and will return an error.
+func (exp *restExperiment) DeleteTag(key string) error {
+	var resp struct{}
+	return exp.store.do(http.MethodPost,
+		"experiments/delete-experiment-tag",
+		deleteExperimentTag{ExperimentID: exp.id, Key: key},
+		&resp)
+}
+
+// Implements [Experiment.Info].
+func (exp *restExperiment) Info() (ExperimentInfo, error) {
+	var resp protos.GetExperiment_Response
+	err := exp.store.do(http.MethodGet, "experiments/get?experiment_id="+url.QueryEscape(exp.id), nil, &resp)
+	if err != nil {
+		return ExperimentInfo{}, err
+	}
+	protoExp := resp.Experiment
+	exp.name = protoExp.GetName()
+	info := ExperimentInfo{
+		ID:               protoExp.GetExperimentId(),
+		Name:             protoExp.GetName(),
+		ArtifactLocation: protoExp.GetArtifactLocation(),
+		LifecycleStage:   protoExp.GetLifecycleStage(),
+		CreationTime:     timeFromMillis(protoExp.GetCreationTime()),
+		LastUpdateTime:   timeFromMillis(protoExp.GetLastUpdateTime()),
+		Tags:             make([]Tag, len(protoExp.Tags)),
+	}
+	for i, tag := range protoExp.Tags {
+		info.Tags[i] = Tag{Key: tag.GetKey(), Val: tag.GetValue()}
+	}
+	return info, nil
+}
+
+// Implements [Experiment.Delete].
+func (exp *restExperiment) Delete() error {
+	var resp protos.DeleteExperiment_Response
+	return exp.store.do(http.MethodPost,
+		"experiments/delete",
+		protos.DeleteExperiment{ExperimentId: &exp.id},
+		&resp)
+}
+
+// Implements [Experiment.Restore].
+func (exp *restExperiment) Restore() error {
+	var resp protos.RestoreExperiment_Response
+	return exp.store.do(http.MethodPost,
+		"experiments/restore",
+		protos.RestoreExperiment{ExperimentId: &exp.id},
+		&resp)
+}
+
 type restRun struct {
 	*protos.RunInfo
 	*protos.RunData
@@ -238,23 +617,41 @@ func (r *restRun) SetTag(key, value string) error {
 		&resp); err != nil {
 		return err
 	}
-	for _, tag := range r.Tags {
+	for _, tag := range r.RunData.Tags {
 		if *tag.Key == key {
 			tag.Value = &value
 			return nil
 		}
 	}
-	r.Tags = append(r.Tags, &protos.RunTag{Key: &key, Value: &value})
+	r.RunData.Tags = append(r.RunData.Tags, &protos.RunTag{Key: &key, Value: &value})
 	return nil
 }
 
 func (r *restRun) GetTag(key string) (string, error) {
-	for _, tag := range r.Tags {
+	for _, tag := range r.RunData.Tags {
 		if *tag.Key == key {
 			return *tag.Value, nil
 		}
 	}
-	return "", fmt.Errorf("tag %s not found", key)
+	return "", newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no tag with key %s", key)
+}
+
+// Implements [Run.DeleteTag].
+func (r *restRun) DeleteTag(key string) error {
+	var resp protos.DeleteTag_Response
+	if err := r.store.do(http.MethodPost,
+		"runs/delete-tag",
+		protos.DeleteTag{RunId: r.RunId, Key: &key},
+		&resp); err != nil {
+		return err
+	}
+	for i, tag := range r.RunData.Tags {
+		if *tag.Key == key {
+			r.RunData.Tags = append(r.RunData.Tags[:i], r.RunData.Tags[i+1:]...)
+			break
+		}
+	}
+	return nil
 }
 
 func (r *restRun) LogArtifact(localPath, artifactPath string) error {
@@ -269,14 +666,46 @@ func (r *restRun) LogArtifact(localPath, artifactPath string) error {
 		return err
 	}
 	if localInfo.IsDir() {
-		if artifactPath != "" {
-			artifactPath = path.Join(artifactPath, localInfo.Name())
-		}
-		return artifactRepo.LogArtifacts(localPath, artifactPath)
+		return artifactRepo.LogArtifacts(localPath, path.Join(artifactPath, localInfo.Name()))
 	}
 	return artifactRepo.LogArtifact(localPath, artifactPath)
 }
 
+func (r *restRun) logArtifacts(localDir, artifactPath string) error {
+	artifactRepo, err := r.store.newArtifactRepo(*r.ArtifactUri)
+	if err != nil {
+		return err
+	}
+	return artifactRepo.LogArtifacts(localDir, artifactPath)
+}
+
+// Implements [Run.ListArtifacts].
+func (r *restRun) ListArtifacts(artifactPath string) ([]ArtifactInfo, error) {
+	artifactRepo, err := r.store.newArtifactRepo(*r.ArtifactUri)
+	if err != nil {
+		return nil, err
+	}
+	return artifactRepo.ListArtifacts(artifactPath)
+}
+
+// Implements [Run.DownloadArtifacts].
+func (r *restRun) DownloadArtifacts(artifactPath, dstDir string) (string, error) {
+	artifactRepo, err := r.store.newArtifactRepo(*r.ArtifactUri)
+	if err != nil {
+		return "", err
+	}
+	return artifactRepo.DownloadArtifacts(artifactPath, dstDir)
+}
+
+// Implements [Run.OpenArtifact].
+func (r *restRun) OpenArtifact(artifactPath string) (io.ReadCloser, error) {
+	artifactRepo, err := r.store.newArtifactRepo(*r.ArtifactUri)
+	if err != nil {
+		return nil, err
+	}
+	return artifactRepo.OpenArtifact(artifactPath)
+}
+
 func (r *restRun) LogMetric(key string, val float64, step int64) error {
 	var resp protos.LogMetric_Response
 	timestamp := time.Now().UnixMilli()
@@ -286,6 +715,87 @@ func (r *restRun) LogMetric(key string, val float64, step int64) error {
 		&resp)
 }
 
+// Implements [Run.GetMetricHistory].
+func (r *restRun) GetMetricHistory(key, pageToken string) ([]LoggedMetric, string, error) {
+	query := url.Values{}
+	query.Set("run_id", *r.RunId)
+	query.Set("metric_key", key)
+	query.Set("max_results", strconv.Itoa(metricHistoryMaxResults))
+	if pageToken != "" {
+		query.Set("page_token", pageToken)
+	}
+	var resp protos.GetMetricHistory_Response
+	if err := r.store.do(http.MethodGet, "metrics/get-history?"+query.Encode(), nil, &resp); err != nil {
+		return nil, "", err
+	}
+	history := make([]LoggedMetric, len(resp.Metrics))
+	for i, m := range resp.Metrics {
+		history[i] = loggedMetricFromProto(m)
+	}
+	return history, resp.GetNextPageToken(), nil
+}
+
+// Implements [Run.GetLatestMetrics].
+func (r *restRun) GetLatestMetrics() ([]LoggedMetric, error) {
+	if err := r.refresh(); err != nil {
+		return nil, err
+	}
+	metrics := make([]LoggedMetric, len(r.GetMetrics()))
+	for i, m := range r.GetMetrics() {
+		metrics[i] = loggedMetricFromProto(m)
+	}
+	return metrics, nil
+}
+
+// refresh replaces the cached run with the latest from the server.
+func (r *restRun) refresh() error {
+	var resp protos.GetRun_Response
+	if err := r.store.do(http.MethodGet, "runs/get?run_id="+url.QueryEscape(*r.RunId), nil, &resp); err != nil {
+		return err
+	}
+	r.RunInfo = resp.Run.Info
+	r.RunData = resp.Run.Data
+	if r.RunData == nil {
+		r.RunData = &protos.RunData{}
+	}
+	return nil
+}
+
+// Implements [Run.Params].
+func (r *restRun) Params() ([]Param, error) {
+	if err := r.refresh(); err != nil {
+		return nil, err
+	}
+	params := make([]Param, len(r.RunData.Params))
+	for i, p := range r.RunData.Params {
+		params[i] = Param{Key: p.GetKey(), Val: p.GetValue()}
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Key < params[j].Key })
+	return params, nil
+}
+
+// Implements [Run.Tags].
+func (r *restRun) Tags() ([]Tag, error) {
+	if err := r.refresh(); err != nil {
+		return nil, err
+	}
+	tags := make([]Tag, len(r.RunData.Tags))
+	for i, t := range r.RunData.Tags {
+		tags[i] = Tag{Key: t.GetKey(), Val: t.GetValue()}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags, nil
+}
+
+func loggedMetricFromProto(m *protos.Metric) LoggedMetric {
+	return LoggedMetric{
+		Key:       m.GetKey(),
+		Val:       m.GetValue(),
+		Step:      m.GetStep(),
+		Timestamp: time.UnixMilli(m.GetTimestamp()),
+	}
+}
+
 func chunkEndIndices(arrayLen, chunkSize int) []int {
 	res := make([]int, 0, (arrayLen+chunkSize-1)/chunkSize)
 	for i := 0; i < arrayLen; i += chunkSize {
@@ -299,7 +809,6 @@ func chunkEndIndices(arrayLen, chunkSize int) []int {
 }
 
 func (r *restRun) LogMetrics(metrics []Metric, step int64) error {
-	const maxMetricsPerBatch = 1000
 	endIdxs := chunkEndIndices(len(metrics), maxMetricsPerBatch)
 	i := 0
 	timestamp := time.Now().UnixMilli()
@@ -324,6 +833,54 @@ func (r *restRun) LogMetrics(metrics []Metric, step int64) error {
 	return nil
 }
 
+// Limits on the size of a single runs/log-batch request.
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/utils/validation.py
+const (
+	maxMetricsPerBatch  = 1000
+	maxParamsPerBatch   = 100
+	maxTagsPerBatch     = 100
+	maxEntitiesPerBatch = 1000
+)
+
+// Implements [Run.LogBatch].
+func (r *restRun) LogBatch(metrics []LoggedMetric, params []Param, tags []Tag) error {
+	now := time.Now().UnixMilli()
+	var resp protos.LogBatch_Response
+	for len(metrics)+len(params)+len(tags) > 0 {
+		numParams := minInt(len(params), maxParamsPerBatch)
+		numTags := minInt(len(tags), maxTagsPerBatch)
+		numMetrics := minInt(len(metrics), minInt(maxMetricsPerBatch, maxEntitiesPerBatch-numParams-numTags))
+		req := protos.LogBatch{RunId: r.RunId}
+		for i := range metrics[:numMetrics] {
+			m := &metrics[i]
+			timestamp := now
+			if !m.Timestamp.IsZero() {
+				timestamp = m.Timestamp.UnixMilli()
+			}
+			req.Metrics = append(req.Metrics, &protos.Metric{Key: &m.Key, Value: &m.Val, Step: &m.Step, Timestamp: &timestamp})
+		}
+		for i := range params[:numParams] {
+			req.Params = append(req.Params, &protos.Param{Key: &params[i].Key, Value: &params[i].Val})
+		}
+		for i := range tags[:numTags] {
+			req.Tags = append(req.Tags, &protos.RunTag{Key: &tags[i].Key, Value: &tags[i].Val})
+		}
+		if err := r.store.do(http.MethodPost, "runs/log-batch", &req, &resp); err != nil {
+			return err
+		}
+		r.cacheTags(tags[:numTags])
+		metrics, params, tags = metrics[numMetrics:], params[numParams:], tags[numTags:]
+	}
+	return nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
 func (r *restRun) LogParam(key, value string) error {
 	var resp protos.LogParam_Response
 	return r.store.do(http.MethodPost,
@@ -333,7 +890,6 @@ func (r *restRun) LogParam(key, value string) error {
 }
 
 func (r *restRun) LogParams(params []Param) error {
-	const maxParamsPerBatch = 100
 	endIdxs := chunkEndIndices(len(params), maxParamsPerBatch)
 	i := 0
 	var resp protos.LogBatch_Response
@@ -372,7 +928,6 @@ func (r *restRun) Name() string {
 }
 
 func (r *restRun) SetTags(tags []Tag) error {
-	const maxTagsPerBatch = 100
 	endIdxs := chunkEndIndices(len(tags), maxTagsPerBatch)
 	i := 0
 	var resp protos.LogBatch_Response
@@ -391,9 +946,16 @@ func (r *restRun) SetTags(tags []Tag) error {
 			return err
 		}
 	}
+	r.cacheTags(tags)
+	return nil
+}
+
+// cacheTags updates the locally cached tags after they have been set on the server.
+func (r *restRun) cacheTags(tags []Tag) {
 	for _, tag := range tags {
+		tag := tag
 		set := false
-		for _, oldTag := range r.Tags {
+		for _, oldTag := range r.RunData.Tags {
 			if *oldTag.Key == tag.Key {
 				oldTag.Value = &tag.Val
 				set = true
@@ -401,19 +963,27 @@ func (r *restRun) SetTags(tags []Tag) error {
 			}
 		}
 		if !set {
-			r.Tags = append(r.Tags, &protos.RunTag{Key: &tag.Key, Value: &tag.Val})
+			r.RunData.Tags = append(r.RunData.Tags, &protos.RunTag{Key: &tag.Key, Value: &tag.Val})
 		}
 	}
-	return nil
 }
 
 func (r *restRun) End() error {
+	return r.SetTerminated(RunStatusFinished, time.Now())
+}
+
+func (r *restRun) Fail() error {
+	return r.SetTerminated(RunStatusFailed, time.Now())
+}
+
+// Implements [Run.SetTerminated].
+func (r *restRun) SetTerminated(status RunStatus, endTime time.Time) error {
 	var resp protos.UpdateRun_Response
-	endTime := time.Now().UnixMilli()
-	status := protos.RunStatus_FINISHED
+	endTimeMillis := endTime.UnixMilli()
+	protoStatus := protos.RunStatus(status)
 	err := r.store.do(http.MethodPost,
 		"runs/update",
-		protos.UpdateRun{RunId: r.RunId, EndTime: &endTime, Status: &status},
+		protos.UpdateRun{RunId: r.RunId, EndTime: &endTimeMillis, Status: &protoStatus},
 		&resp)
 	if err != nil {
 		return err
@@ -422,21 +992,52 @@ func (r *restRun) End() error {
 	return nil
 }
 
-func (r *restRun) Fail() error {
-	var resp protos.UpdateRun_Response
-	endTime := time.Now().UnixMilli()
-	status := protos.RunStatus_FAILED
-	err := r.store.do(http.MethodPost,
-		"runs/update",
-		protos.UpdateRun{RunId: r.RunId, EndTime: &endTime, Status: &status},
-		&resp)
-	if err != nil {
+// Implements [Run.Delete].
+func (r *restRun) Delete() error {
+	var resp protos.DeleteRun_Response
+	if err := r.store.do(http.MethodPost,
+		"runs/delete",
+		protos.DeleteRun{RunId: r.RunId},
+		&resp); err != nil {
 		return err
 	}
-	endIfActive(r)
+	lifecycleStage := LifecycleStageDeleted
+	r.LifecycleStage = &lifecycleStage
 	return nil
 }
 
+// Implements [Run.Restore].
+func (r *restRun) Restore() error {
+	var resp protos.RestoreRun_Response
+	if err := r.store.do(http.MethodPost,
+		"runs/restore",
+		protos.RestoreRun{RunId: r.RunId},
+		&resp); err != nil {
+		return err
+	}
+	lifecycleStage := LifecycleStageActive
+	r.LifecycleStage = &lifecycleStage
+	return nil
+}
+
+// Implements [Run.Info].
+func (r *restRun) Info() (RunInfo, error) {
+	if err := r.refresh(); err != nil {
+		return RunInfo{}, err
+	}
+	return RunInfo{
+		ID:             r.GetRunId(),
+		Name:           r.GetRunName(),
+		ExperimentID:   r.GetExperimentId(),
+		UserID:         r.GetUserId(),
+		Status:         RunStatus(r.GetStatus()),
+		StartTime:      timeFromMillis(r.GetStartTime()),
+		EndTime:        timeFromMillis(r.GetEndTime()),
+		ArtifactURI:    r.GetArtifactUri(),
+		LifecycleStage: r.GetLifecycleStage(),
+	}, nil
+}
+
 func (r *restRun) UIURL() string {
 	middle := ""
 	if strings.Contains(r.store.baseURL, "databricks.com") {
@@ -445,6 +1046,13 @@ func (r *restRun) UIURL() string {
 	return fmt.Sprintf("%s/%sexperiments/%s/runs/%s", r.store.baseURL, middle, *r.ExperimentId, *r.RunId)
 }
 
+// Implements [Run.WithContext].
+func (r *restRun) WithContext(ctx context.Context) Run {
+	c := *r
+	c.store = r.store.WithContext(ctx).(*RESTStore)
+	return &c
+}
+
 func (r *restRun) ID() string {
 	return *r.RunId
 }
@@ -454,12 +1062,12 @@ func (r *restRun) ExperimentID() string {
 }
 
 func (r *restRun) GetParam(key string) (string, error) {
-	for _, param := range r.Params {
+	for _, param := range r.RunData.Params {
 		if *param.Key == key {
 			return *param.Value, nil
 		}
 	}
-	return "", fmt.Errorf("param with key %s not found", key)
+	return "", newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "no param with key %s", key)
 }
 
 func (store *RESTStore) newArtifactRepo(artifactURI string) (ArtifactRepo, error) {
@@ -471,7 +1079,11 @@ func (store *RESTStore) newArtifactRepo(artifactURI string) (ArtifactRepo, error
 	case "dbfs":
 		return NewDBFSArtifactRepo(store, artifactURI)
 	case "file", "":
-		return NewFileArtifactRepo(parsed.Path)
+		repo, err := NewFileArtifactRepo(parsed.Path)
+		if err != nil {
+			return nil, err
+		}
+		return repo.WithContext(store.ctx), nil
 	}
 	return nil, fmt.Errorf("support for artifact repo with URI scheme %s not implemented", parsed.Scheme)
 }
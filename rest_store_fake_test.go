@@ -0,0 +1,568 @@
+package mlflow_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mlflow "github.com/Astera-org/mlflow-go"
+	"github.com/Astera-org/mlflow-go/mlflowtest"
+	"github.com/Astera-org/mlflow-go/protos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests use the fake server in package mlflowtest, which imports this package,
+// so they are in an external test package.
+
+func newFakeServer(t *testing.T, opts ...mlflow.RESTStoreOption) (*mlflowtest.Server, *mlflow.RESTStore) {
+	server := mlflowtest.NewServer()
+	t.Cleanup(server.Close)
+	opts = append([]mlflow.RESTStoreOption{mlflow.WithMaxRetries(0)}, opts...)
+	store, err := mlflow.NewRESTStore(server.URL, "", opts...)
+	require.NoError(t, err)
+	return server, store.(*mlflow.RESTStore)
+}
+
+// logBatches decodes the runs/log-batch requests received by server.
+func logBatches(t *testing.T, server *mlflowtest.Server) []*protos.LogBatch {
+	var batches []*protos.LogBatch
+	for _, req := range server.Requests("runs/log-batch") {
+		batch := &protos.LogBatch{}
+		require.NoError(t, json.Unmarshal(req.Body, batch))
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+func TestRESTExperiment(t *testing.T) {
+	_, store := newFakeServer(t)
+
+	exp, err := store.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	same, err := store.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	assert.Equal(t, exp.ID(), same.ID())
+	_, err = store.CreateExperiment("exp0")
+	assert.ErrorIs(t, err, mlflow.ErrAlreadyExists)
+	_, err = store.GetExperiment("missing")
+	assert.ErrorIs(t, err, mlflow.ErrNotFound)
+	def, err := store.GetExperiment("")
+	require.NoError(t, err)
+	assert.Equal(t, "Default", def.Name())
+
+	require.NoError(t, exp.SetName("exp1"))
+	assert.Equal(t, "exp1", exp.Name())
+	require.NoError(t, exp.SetTag("team", "vision"))
+	val, err := exp.GetTag("team")
+	require.NoError(t, err)
+	assert.Equal(t, "vision", val)
+	require.NoError(t, exp.DeleteTag("team"))
+	_, err = exp.GetTag("team")
+	assert.ErrorIs(t, err, mlflow.ErrNotFound)
+
+	require.NoError(t, exp.Delete())
+	info, err := exp.Info()
+	require.NoError(t, err)
+	assert.Equal(t, mlflow.LifecycleStageDeleted, info.LifecycleStage)
+	exps, err := store.ExperimentsByName()
+	require.NoError(t, err)
+	assert.NotContains(t, exps, "exp1")
+	require.NoError(t, exp.Restore())
+	exps, err = store.ExperimentsByName()
+	require.NoError(t, err)
+	assert.Contains(t, exps, "exp1")
+}
+
+func TestRESTStoreExperimentsByNamePagination(t *testing.T) {
+	server, store := newFakeServer(t)
+	server.SetPageSize(2)
+	for i := 0; i < 4; i++ {
+		_, err := store.CreateExperiment(fmt.Sprintf("exp%d", i))
+		require.NoError(t, err)
+	}
+
+	exps, err := store.ExperimentsByName()
+	require.NoError(t, err)
+	assert.Len(t, exps, 5)
+	assert.Contains(t, exps, "Default")
+	assert.Contains(t, exps, "exp3")
+	assert.Len(t, server.Requests("experiments/search"), 3)
+}
+
+func TestRESTRun(t *testing.T) {
+	server, store := newFakeServer(t)
+	exp, err := store.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	startTime := time.UnixMilli(1700000000000)
+	run, err := exp.CreateRun("run0", mlflow.WithStartTime(startTime), mlflow.WithTags([]mlflow.Tag{
+		{Key: mlflow.UserTagKey, Val: "alice"},
+		{Key: "model", Val: "resnet"},
+	}))
+	require.NoError(t, err)
+	serverRun, err := server.Tracking.GetRun(run.ID())
+	require.NoError(t, err)
+
+	require.NoError(t, run.LogMetric("loss", 2, 0))
+	require.NoError(t, run.LogMetrics([]mlflow.Metric{{Key: "loss", Val: 1}, {Key: "acc", Val: 0.5}}, 1))
+	require.NoError(t, run.LogParam("lr", "0.1"))
+	assert.ErrorIs(t, run.LogParam("lr", "0.2"), mlflow.ErrInvalidParameter)
+	require.NoError(t, run.SetTag("stage", "train"))
+	require.NoError(t, run.SetName("run1"))
+	mlflowtest.AssertMetric(t, serverRun, "loss", 1, 1)
+	mlflowtest.AssertMetric(t, serverRun, "acc", 1, 0.5)
+	mlflowtest.AssertParam(t, serverRun, "lr", "0.1")
+	mlflowtest.AssertTag(t, serverRun, "model", "resnet")
+	mlflowtest.AssertTag(t, serverRun, "stage", "train")
+
+	val, err := run.GetTag("stage")
+	require.NoError(t, err)
+	assert.Equal(t, "train", val)
+	require.NoError(t, run.DeleteTag("stage"))
+	_, err = run.GetTag("stage")
+	assert.ErrorIs(t, err, mlflow.ErrNotFound)
+	params, err := run.Params()
+	require.NoError(t, err)
+	assert.Equal(t, []mlflow.Param{{Key: "lr", Val: "0.1"}}, params)
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 2.0, history[0].Val)
+	latest, err := run.GetLatestMetrics()
+	require.NoError(t, err)
+	assert.Len(t, latest, 2)
+
+	endTime := startTime.Add(time.Hour)
+	require.NoError(t, run.SetTerminated(mlflow.RunStatusFailed, endTime))
+	mlflowtest.AssertStatus(t, serverRun, mlflow.RunStatusFailed)
+	info, err := run.Info()
+	require.NoError(t, err)
+	assert.Equal(t, mlflow.RunInfo{
+		ID:             run.ID(),
+		Name:           "run1",
+		ExperimentID:   exp.ID(),
+		UserID:         "alice",
+		Status:         mlflow.RunStatusFailed,
+		StartTime:      startTime,
+		EndTime:        endTime,
+		ArtifactURI:    "dbfs:/databricks/mlflow-tracking/" + exp.ID() + "/" + run.ID() + "/artifacts",
+		LifecycleStage: mlflow.LifecycleStageActive,
+	}, info)
+
+	require.NoError(t, run.Delete())
+	runs, _, err := store.SearchRuns([]string{exp.ID()}, "", nil, "")
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+	require.NoError(t, run.Restore())
+	got, err := exp.GetRun(run.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "run1", got.Name())
+	_, err = store.GetRun("missing")
+	assert.ErrorIs(t, err, mlflow.ErrNotFound)
+}
+
+func TestRESTStoreSearchRunsPagination(t *testing.T) {
+	server, store := newFakeServer(t)
+	server.SetPageSize(2)
+	exp, err := store.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		run, err := exp.CreateRun(fmt.Sprintf("run%d", i))
+		require.NoError(t, err)
+		require.NoError(t, run.LogParam("i", fmt.Sprint(i)))
+	}
+
+	runs, pageToken, err := store.SearchRuns([]string{exp.ID()}, "", []string{"params.i"}, "")
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "run0", runs[0].Name())
+	require.NotEmpty(t, pageToken)
+	runs, pageToken, err = store.SearchRuns([]string{exp.ID()}, "", []string{"params.i"}, pageToken)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "run2", runs[0].Name())
+	assert.Empty(t, pageToken)
+}
+
+func TestRESTRunBatchChunking(t *testing.T) {
+	server, store := newFakeServer(t)
+	exp, err := store.GetExperiment("")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	serverRun, err := server.Tracking.GetRun(run.ID())
+	require.NoError(t, err)
+
+	params := make([]mlflow.Param, 250)
+	for i := range params {
+		params[i] = mlflow.Param{Key: fmt.Sprintf("param%d", i), Val: "val"}
+	}
+	require.NoError(t, run.LogParams(params))
+	batches := logBatches(t, server)
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0].Params, 100)
+	assert.Len(t, batches[1].Params, 100)
+	assert.Len(t, batches[2].Params, 50)
+	mlflowtest.AssertParam(t, serverRun, "param249", "val")
+
+	tags := make([]mlflow.Tag, 150)
+	for i := range tags {
+		tags[i] = mlflow.Tag{Key: fmt.Sprintf("tag%d", i), Val: "val"}
+	}
+	require.NoError(t, run.SetTags(tags))
+	batches = logBatches(t, server)[3:]
+	require.Len(t, batches, 2)
+	assert.Len(t, batches[0].Tags, 100)
+	assert.Len(t, batches[1].Tags, 50)
+	mlflowtest.AssertTag(t, serverRun, "tag149", "val")
+	val, err := run.GetTag("tag149")
+	require.NoError(t, err)
+	assert.Equal(t, "val", val)
+}
+
+func TestRESTStoreRetries(t *testing.T) {
+	server, store := newFakeServer(t, mlflow.WithMaxRetries(2), mlflow.WithBackoffFactor(time.Millisecond))
+	exp, err := store.GetExperiment("")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+
+	server.Fail("runs/log-metric", 2, http.StatusServiceUnavailable, protos.ErrorCode_TEMPORARILY_UNAVAILABLE)
+	require.NoError(t, run.LogMetric("loss", 1, 0))
+	assert.Len(t, server.Requests("runs/log-metric"), 3)
+
+	server.Fail("runs/log-metric", 3, http.StatusTooManyRequests, protos.ErrorCode_REQUEST_LIMIT_EXCEEDED)
+	err = run.LogMetric("loss", 1, 1)
+	var mlflowErr *mlflow.Error
+	require.ErrorAs(t, err, &mlflowErr)
+	assert.Equal(t, http.StatusTooManyRequests, mlflowErr.StatusCode)
+	assert.Equal(t, "REQUEST_LIMIT_EXCEEDED", mlflowErr.Code)
+	assert.Len(t, server.Requests("runs/log-metric"), 6)
+
+	// Client errors are not retried.
+	server.Fail("runs/log-parameter", 1, http.StatusBadRequest, protos.ErrorCode_INVALID_PARAMETER_VALUE)
+	assert.ErrorIs(t, run.LogParam("lr", "0.1"), mlflow.ErrInvalidParameter)
+	assert.Len(t, server.Requests("runs/log-parameter"), 1)
+	serverRun, err := server.Tracking.GetRun(run.ID())
+	require.NoError(t, err)
+	mlflowtest.AssertMetric(t, serverRun, "loss", 0, 1)
+}
+
+func TestRESTStoreLatency(t *testing.T) {
+	server, store := newFakeServer(t)
+	server.SetLatency(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := store.WithContext(ctx).GetExperiment("")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDBFSArtifactRepo(t *testing.T) {
+	server, store := newFakeServer(t)
+	exp, err := store.GetExperiment("")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	serverRun, err := server.Tracking.GetRun(run.ID())
+	require.NoError(t, err)
+
+	localDir := filepath.Join(t.TempDir(), "model")
+	require.NoError(t, os.MkdirAll(filepath.Join(localDir, "layers"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "config.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "layers", "0.bin"), []byte("weights"), 0644))
+
+	require.NoError(t, run.LogArtifact(filepath.Join(localDir, "config.json"), "checkpoints"))
+	mlflowtest.AssertArtifact(t, serverRun, "checkpoints/config.json", []byte("{}"))
+	require.NoError(t, run.LogArtifact(localDir, ""))
+	mlflowtest.AssertArtifact(t, serverRun, "model/config.json", []byte("{}"))
+	mlflowtest.AssertArtifact(t, serverRun, "model/layers/0.bin", []byte("weights"))
+
+	info, err := run.Info()
+	require.NoError(t, err)
+	repo, err := mlflow.NewDBFSArtifactRepo(store, info.ArtifactURI)
+	require.NoError(t, err)
+	require.NoError(t, repo.LogArtifacts(localDir, "copy"))
+	mlflowtest.AssertArtifact(t, serverRun, "copy/layers/0.bin", []byte("weights"))
+
+	server.Fail("artifacts/credentials-for-write", 1, http.StatusForbidden, protos.ErrorCode_PERMISSION_DENIED)
+	assert.ErrorIs(t, repo.LogArtifact(filepath.Join(localDir, "config.json"), ""), mlflow.ErrPermissionDenied)
+	server.Fail("/upload/"+run.ID()+"/config.json", 1, http.StatusForbidden, protos.ErrorCode_PERMISSION_DENIED)
+	assert.Error(t, repo.LogArtifact(filepath.Join(localDir, "config.json"), ""))
+	assert.Len(t, server.Requests("/upload/"+run.ID()+"/config.json"), 1)
+}
+
+func TestDBFSArtifactRepoMultipartUpload(t *testing.T) {
+	mlflow.SetDBFSUploadPartSize(t, 4)
+	server, store := newFakeServer(t, mlflow.WithMaxRetries(2), mlflow.WithBackoffFactor(time.Millisecond))
+	exp, err := store.GetExperiment("")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	serverRun, err := server.Tracking.GetRun(run.ID())
+	require.NoError(t, err)
+	localPath := filepath.Join(t.TempDir(), "model.bin")
+	require.NoError(t, os.WriteFile(localPath, []byte("0123456789"), 0644))
+
+	require.NoError(t, run.LogArtifact(localPath, "checkpoints"))
+	mlflowtest.AssertArtifact(t, serverRun, "checkpoints/model.bin", []byte("0123456789"))
+	assert.Empty(t, server.Requests("artifacts/credentials-for-write"))
+	assert.Len(t, server.Requests("artifacts/create-multipart-upload"), 1)
+	assert.Len(t, server.Requests("artifacts/complete-multipart-upload"), 1)
+	parts := server.Requests("/upload-part/1/3")
+	require.Len(t, parts, 1)
+	assert.Equal(t, []byte("89"), parts[0].Body)
+
+	// Parts are retried individually.
+	server.Fail("/upload-part/2/2", 2, http.StatusServiceUnavailable, protos.ErrorCode_TEMPORARILY_UNAVAILABLE)
+	require.NoError(t, run.LogArtifact(localPath, "retried"))
+	mlflowtest.AssertArtifact(t, serverRun, "retried/model.bin", []byte("0123456789"))
+	assert.Len(t, server.Requests("/upload-part/2/1"), 1)
+	assert.Len(t, server.Requests("/upload-part/2/2"), 3)
+
+	// A new URL is requested if the presigned one has expired.
+	server.Fail("/upload-part/3/1", 1, http.StatusForbidden, protos.ErrorCode_PERMISSION_DENIED)
+	require.NoError(t, run.LogArtifact(localPath, "expired"))
+	mlflowtest.AssertArtifact(t, serverRun, "expired/model.bin", []byte("0123456789"))
+	assert.Len(t, server.Requests("artifacts/get-presigned-upload-part-url"), 1)
+
+	// The upload is aborted if a part fails.
+	server.Fail("/upload-part/4/2", 3, http.StatusInternalServerError, protos.ErrorCode_INTERNAL_ERROR)
+	assert.Error(t, run.LogArtifact(localPath, "failed"))
+	assert.Len(t, server.Requests("/upload-part/4/3"), 0)
+	aborts := server.Requests("/upload-abort/4")
+	require.Len(t, aborts, 1)
+	assert.Equal(t, http.MethodDelete, aborts[0].Method)
+	assert.Len(t, server.Requests("artifacts/complete-multipart-upload"), 3)
+	assert.NotContains(t, serverRun.(*mlflowtest.Run).ArtifactRepo().Paths(), "failed/model.bin")
+}
+
+func TestDBFSArtifactRepoLogArtifactsConcurrently(t *testing.T) {
+	const numFiles = 5
+	server, store := newFakeServer(t, mlflow.WithArtifactUploadWorkers(numFiles))
+	exp, err := store.GetExperiment("")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	serverRun, err := server.Tracking.GetRun(run.ID())
+	require.NoError(t, err)
+	localDir := filepath.Join(t.TempDir(), "model")
+	require.NoError(t, os.MkdirAll(localDir, 0755))
+	for i := 0; i < numFiles; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(localDir, fmt.Sprintf("%d.bin", i)), []byte{byte(i)}, 0644))
+	}
+
+	// One credentials request, then all uploads at once, so much less than numFiles+1 round trips.
+	const latency = 200 * time.Millisecond
+	server.SetLatency(latency)
+	start := time.Now()
+	require.NoError(t, run.LogArtifact(localDir, ""))
+	assert.Less(t, time.Since(start), numFiles*latency)
+	server.SetLatency(0)
+	credReqs := server.Requests("artifacts/credentials-for-write")
+	require.Len(t, credReqs, 1)
+	var credReq protos.GetCredentialsForWrite
+	require.NoError(t, json.Unmarshal(credReqs[0].Body, &credReq))
+	assert.ElementsMatch(t, []string{"model/0.bin", "model/1.bin", "model/2.bin", "model/3.bin", "model/4.bin"}, credReq.Path)
+	for i := 0; i < numFiles; i++ {
+		mlflowtest.AssertArtifact(t, serverRun, fmt.Sprintf("model/%d.bin", i), []byte{byte(i)})
+	}
+
+	// A failed file doesn't stop the others, and all failures are reported.
+	info, err := run.Info()
+	require.NoError(t, err)
+	repo, err := mlflow.NewDBFSArtifactRepo(store, info.ArtifactURI)
+	require.NoError(t, err)
+	server.Fail("/upload/"+run.ID()+"/retry/1.bin", 1, http.StatusForbidden, protos.ErrorCode_PERMISSION_DENIED)
+	server.Fail("/upload/"+run.ID()+"/retry/3.bin", 1, http.StatusForbidden, protos.ErrorCode_PERMISSION_DENIED)
+	err = repo.LogArtifacts(localDir, "retry")
+	var uploadErr *mlflow.ArtifactUploadError
+	require.ErrorAs(t, err, &uploadErr)
+	require.Len(t, uploadErr.Errors, 2)
+	assert.Contains(t, uploadErr.Errors[0].Error(), "1.bin")
+	assert.Contains(t, uploadErr.Errors[1].Error(), "3.bin")
+	paths := serverRun.(*mlflowtest.Run).ArtifactRepo().Paths()
+	assert.Contains(t, paths, "retry/4.bin")
+	assert.NotContains(t, paths, "retry/1.bin")
+
+	// If getting the credentials for a batch fails, the other batches are still uploaded.
+	mlflow.SetDBFSCredentialsBatchSize(t, 2)
+	server.Fail("artifacts/credentials-for-write", 1, http.StatusForbidden, protos.ErrorCode_PERMISSION_DENIED)
+	err = repo.LogArtifacts(localDir, "denied")
+	assert.ErrorIs(t, err, mlflow.ErrPermissionDenied)
+	require.ErrorAs(t, err, &uploadErr)
+	assert.Len(t, uploadErr.Errors, 2)
+	paths = serverRun.(*mlflowtest.Run).ArtifactRepo().Paths()
+	assert.Contains(t, paths, "denied/2.bin")
+	assert.Contains(t, paths, "denied/4.bin")
+	assert.NotContains(t, paths, "denied/0.bin")
+}
+
+func TestDBFSArtifactRepoRead(t *testing.T) {
+	server, store := newFakeServer(t, mlflow.WithMaxRetries(2), mlflow.WithBackoffFactor(time.Millisecond))
+	exp, err := store.GetExperiment("")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	localDir := filepath.Join(t.TempDir(), "model")
+	require.NoError(t, os.MkdirAll(filepath.Join(localDir, "layers"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "config.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "layers", "0.bin"), []byte("weights"), 0644))
+	require.NoError(t, run.LogArtifact(localDir, ""))
+
+	infos, err := run.ListArtifacts("model")
+	require.NoError(t, err)
+	assert.Equal(t, []mlflow.ArtifactInfo{
+		{Path: "model/config.json", Size: 2},
+		{Path: "model/layers", IsDir: true},
+	}, infos)
+	infos, err = run.ListArtifacts("model/config.json")
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+
+	// Downloads that fail to start are retried.
+	server.Fail("/download/"+run.ID()+"/model/layers/0.bin", 1, http.StatusServiceUnavailable, protos.ErrorCode_TEMPORARILY_UNAVAILABLE)
+	r, err := run.OpenArtifact("model/layers/0.bin")
+	require.NoError(t, err)
+	contents, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, []byte("weights"), contents)
+	assert.Len(t, server.Requests("/download/"+run.ID()+"/model/layers/0.bin"), 2)
+	_, err = run.OpenArtifact("missing.bin")
+	assert.ErrorIs(t, err, mlflow.ErrNotFound)
+
+	dstDir := t.TempDir()
+	localPath, err := run.DownloadArtifacts("model", dstDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dstDir, "model"), localPath)
+	contents, err = os.ReadFile(filepath.Join(dstDir, "model", "layers", "0.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("weights"), contents)
+	contents, err = os.ReadFile(filepath.Join(dstDir, "model", "config.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("{}"), contents)
+	_, err = run.DownloadArtifacts("missing", t.TempDir())
+	assert.ErrorIs(t, err, mlflow.ErrNotFound)
+}
+
+func TestExportDBFSArtifacts(t *testing.T) {
+	_, store := newFakeServer(t)
+	exp, err := store.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	localDir := filepath.Join(t.TempDir(), "model")
+	require.NoError(t, os.MkdirAll(filepath.Join(localDir, "layers"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "layers", "0.bin"), []byte("weights"), 0644))
+	require.NoError(t, run.LogArtifact(localDir, ""))
+
+	var archive bytes.Buffer
+	exported, err := mlflow.Export(store, &archive, mlflow.ExportOptions{ExperimentIDs: []string{exp.ID()}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{run.ID()}, exported.RunIDs)
+	dst, err := mlflow.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	imported, err := mlflow.Import(dst, &archive)
+	require.NoError(t, err)
+	dstExp, err := dst.GetExperiment(imported.ExperimentIDs[exp.ID()])
+	require.NoError(t, err)
+	dstRun, err := dstExp.GetRun(imported.RunIDs[run.ID()])
+	require.NoError(t, err)
+	r, err := dstRun.OpenArtifact("model/layers/0.bin")
+	require.NoError(t, err)
+	defer r.Close()
+	contents, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("weights"), contents)
+}
+
+func TestImportDBFSArtifacts(t *testing.T) {
+	src, err := mlflow.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := src.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	localDir := filepath.Join(t.TempDir(), "model")
+	require.NoError(t, os.MkdirAll(filepath.Join(localDir, "layers"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "config.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "layers", "0.bin"), []byte("weights"), 0644))
+	require.NoError(t, run.LogArtifact(localDir, ""))
+	var archive bytes.Buffer
+	_, err = mlflow.Export(src, &archive, mlflow.ExportOptions{})
+	require.NoError(t, err)
+
+	server, dst := newFakeServer(t)
+	imported, err := mlflow.Import(dst, &archive)
+	require.NoError(t, err)
+	serverRun, err := server.Tracking.GetRun(imported.RunIDs[run.ID()])
+	require.NoError(t, err)
+	mlflowtest.AssertArtifact(t, serverRun, "model/config.json", []byte("{}"))
+	mlflowtest.AssertArtifact(t, serverRun, "model/layers/0.bin", []byte("weights"))
+	// All files are uploaded as one batch.
+	credReqs := server.Requests("artifacts/credentials-for-write")
+	require.Len(t, credReqs, 1)
+	var credReq protos.GetCredentialsForWrite
+	require.NoError(t, json.Unmarshal(credReqs[0].Body, &credReq))
+	assert.ElementsMatch(t, []string{"model/config.json", "model/layers/0.bin"}, credReq.Path)
+}
+
+// artifactPaths returns the paths of all files in repo under artifactPath.
+func artifactPaths(t *testing.T, repo mlflow.ArtifactRepo, artifactPath string) []string {
+	infos, err := repo.ListArtifacts(artifactPath)
+	require.NoError(t, err)
+	var paths []string
+	for _, info := range infos {
+		if info.IsDir {
+			paths = append(paths, artifactPaths(t, repo, info.Path)...)
+		} else {
+			paths = append(paths, info.Path)
+		}
+	}
+	return paths
+}
+
+func TestArtifactRepoLayout(t *testing.T) {
+	_, store := newFakeServer(t)
+	exp, err := store.GetExperiment("")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	info, err := run.Info()
+	require.NoError(t, err)
+	dbfsRepo, err := mlflow.NewDBFSArtifactRepo(store, info.ArtifactURI)
+	require.NoError(t, err)
+	fileRepo, err := mlflow.NewFileArtifactRepo(t.TempDir())
+	require.NoError(t, err)
+	localDir := filepath.Join(t.TempDir(), "model")
+	require.NoError(t, os.MkdirAll(filepath.Join(localDir, "layers"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "config.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "layers", "0.bin"), []byte("weights"), 0644))
+
+	for _, repo := range []mlflow.ArtifactRepo{fileRepo, dbfsRepo} {
+		require.NoError(t, repo.LogArtifact(filepath.Join(localDir, "config.json"), ""))
+		require.NoError(t, repo.LogArtifact(filepath.Join(localDir, "config.json"), "file"))
+		require.NoError(t, repo.LogArtifact(localDir, "dir"))
+		require.NoError(t, repo.LogArtifacts(localDir, "contents"))
+		assert.Equal(t, []string{
+			"config.json",
+			"contents/config.json",
+			"contents/layers/0.bin",
+			"dir/model/config.json",
+			"dir/model/layers/0.bin",
+			"file/config.json",
+		}, artifactPaths(t, repo, ""), "%T", repo)
+	}
+
+	// Runs log a directory under its name, whether or not artifactPath is set.
+	require.NoError(t, run.LogArtifact(localDir, ""))
+	require.NoError(t, run.LogArtifact(localDir, "run"))
+	assert.Subset(t, artifactPaths(t, dbfsRepo, ""), []string{"model/layers/0.bin", "run/model/layers/0.bin"})
+}
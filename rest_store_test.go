@@ -1,9 +1,22 @@
 package mlflow
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/Astera-org/mlflow-go/protos"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestChunkEndIndices(t *testing.T) {
@@ -28,3 +41,189 @@ func TestChunkEndIndices(t *testing.T) {
 	idxs = chunkEndIndices(22, 10)
 	assert.Equal(t, []int{10, 20, 22}, idxs)
 }
+
+func TestErrorFromResponse(t *testing.T) {
+	err := errorFromResponse(http.StatusNotFound, []byte(`{"error_code": "RESOURCE_DOES_NOT_EXIST", "message": "Run 'abc' not found"}`))
+	assert.Equal(t, "RESOURCE_DOES_NOT_EXIST", err.Code)
+	assert.Equal(t, "Run 'abc' not found", err.Message)
+	assert.Equal(t, http.StatusNotFound, err.StatusCode)
+	wrapped := fmt.Errorf("GET runs/get failed: %w", err)
+	assert.ErrorIs(t, wrapped, ErrNotFound)
+	assert.False(t, errors.Is(wrapped, ErrAlreadyExists))
+	var mlflowErr *Error
+	assert.True(t, errors.As(wrapped, &mlflowErr))
+	assert.Equal(t, err, mlflowErr)
+
+	err = errorFromResponse(http.StatusBadRequest, []byte(`{"error_code": "INVALID_PARAMETER_VALUE", "message": "bad"}`))
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+	err = errorFromResponse(http.StatusBadRequest, []byte(`{"error_code": "RESOURCE_ALREADY_EXISTS", "message": "exists"}`))
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+
+	// Not JSON, e.g. from a proxy.
+	err = errorFromResponse(http.StatusForbidden, []byte("<html>Forbidden</html>\n"))
+	assert.Equal(t, "PERMISSION_DENIED", err.Code)
+	assert.Equal(t, "<html>Forbidden</html>", err.Message)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	err = errorFromResponse(http.StatusTeapot, nil)
+	assert.Equal(t, "UNPARSEABLE_HTTP_ERROR", err.Code)
+}
+
+func TestRESTStoreWithContext(t *testing.T) {
+	// The server never responds, so requests only return when cancelled.
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+	store, err := NewRESTStore(server.URL, "")
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = store.WithContext(ctx).GetExperiment("0")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRESTStoreTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"experiment": {"experiment_id": "0", "name": "Default"}}`))
+	}))
+	defer server.Close()
+	certPath := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(certPath, certPEM, 0644))
+
+	for _, tc := range []struct {
+		name    string
+		config  map[string]string
+		wantErr bool
+	}{
+		{"system CAs", map[string]string{}, true},
+		{"server cert", map[string]string{ServerCertPathEnvName: certPath}, false},
+		{"insecure", map[string]string{InsecureTLSEnvName: "true"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := restStoreOptionsFromConfig(func(key string) string { return tc.config[key] })
+			require.NoError(t, err)
+			store, err := NewRESTStore(server.URL, "", append(opts, WithMaxRetries(0))...)
+			require.NoError(t, err)
+			_, err = store.GetExperiment("0")
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	for _, config := range []map[string]string{
+		{InsecureTLSEnvName: "true", ServerCertPathEnvName: certPath},
+		{InsecureTLSEnvName: "yes"},
+		{ServerCertPathEnvName: filepath.Join(t.TempDir(), "missing.pem")},
+		{ClientCertPathEnvName: certPath}, // No private key.
+	} {
+		_, err := restStoreOptionsFromConfig(func(key string) string { return config[key] })
+		assert.Error(t, err, config)
+	}
+}
+
+func TestRESTStoreHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{"experiment": {"experiment_id": "0", "name": "Default"}}`))
+	}))
+	defer server.Close()
+
+	store, err := NewRESTStore(server.URL, "", WithTimeout(10*time.Millisecond), WithMaxRetries(0))
+	require.NoError(t, err)
+	_, err = store.GetExperiment("0")
+	assert.Error(t, err)
+	assert.Zero(t, http.DefaultClient.Timeout, "the default client must not be modified")
+
+	var requests int
+	client := &http.Client{Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	store, err = NewRESTStore(server.URL, "", WithHTTPClient(client))
+	require.NoError(t, err)
+	_, err = store.GetExperiment("0")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	_, err = NewRESTStore(server.URL, "", WithHTTPClient(client), WithTLSConfig(&tls.Config{}))
+	assert.Error(t, err)
+}
+
+func TestRESTStoreBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "alice" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"experiment": {"experiment_id": "0", "name": "Default"}}`))
+	}))
+	defer server.Close()
+
+	config := struct {
+		MLFLOW_TRACKING_URI      string
+		MLFLOW_TRACKING_USERNAME string
+		MLFLOW_TRACKING_PASSWORD string
+		MLFLOW_TRACKING_TOKEN    string
+	}{server.URL, "alice", "secret", "ignored"}
+	tracking, err := newTracking("", "", nil, func(key string) string {
+		return stringFieldFromStruct(key, config)
+	})
+	require.NoError(t, err)
+	_, err = tracking.GetExperiment("0")
+	assert.NoError(t, err)
+
+	store, err := NewRESTStore(server.URL, "", WithBasicAuth("alice", "wrong"), WithMaxRetries(0))
+	require.NoError(t, err)
+	_, err = store.GetExperiment("0")
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestRESTRunLogBatch(t *testing.T) {
+	var batches []*protos.LogBatch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batch := &protos.LogBatch{}
+		if assert.NoError(t, json.NewDecoder(r.Body).Decode(batch)) {
+			batches = append(batches, batch)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	store, err := NewRESTStore(server.URL, "")
+	require.NoError(t, err)
+	runID := "run0"
+	run := &restRun{&protos.RunInfo{RunId: &runID}, &protos.RunData{}, store.(*RESTStore)}
+
+	metrics := make([]LoggedMetric, 1500)
+	for i := range metrics {
+		metrics[i] = LoggedMetric{Key: "loss", Val: float64(i), Step: int64(i)}
+	}
+	metrics[0].Timestamp = time.UnixMilli(1234)
+	params := make([]Param, 150)
+	for i := range params {
+		params[i] = Param{Key: fmt.Sprintf("param%d", i), Val: "val"}
+	}
+	require.NoError(t, run.LogBatch(metrics, params, []Tag{{Key: "tag0", Val: "val0"}}))
+
+	require.Len(t, batches, 2)
+	assert.Len(t, batches[0].Metrics, 899)
+	assert.Len(t, batches[0].Params, 100)
+	assert.Len(t, batches[0].Tags, 1)
+	assert.Len(t, batches[1].Metrics, 601)
+	assert.Len(t, batches[1].Params, 50)
+	assert.Empty(t, batches[1].Tags)
+	assert.Equal(t, int64(1234), batches[0].Metrics[0].GetTimestamp())
+	assert.NotZero(t, batches[0].Metrics[1].GetTimestamp())
+	assert.Equal(t, int64(1499), batches[1].Metrics[600].GetStep())
+	val, err := run.GetTag("tag0")
+	require.NoError(t, err)
+	assert.Equal(t, "val0", val)
+}
@@ -0,0 +1,735 @@
+package mlflow
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Astera-org/mlflow-go/protos"
+)
+
+// Implements the subset of SQL that MLFlow uses for search filters.
+// Based on
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/utils/search_utils.py
+
+const (
+	searchEntityMetric    = "metric"
+	searchEntityParam     = "parameter"
+	searchEntityTag       = "tag"
+	searchEntityAttribute = "attribute"
+)
+
+var searchEntityAliases = map[string]string{
+	"metric":     searchEntityMetric,
+	"metrics":    searchEntityMetric,
+	"param":      searchEntityParam,
+	"params":     searchEntityParam,
+	"parameter":  searchEntityParam,
+	"parameters": searchEntityParam,
+	"tag":        searchEntityTag,
+	"tags":       searchEntityTag,
+	"attribute":  searchEntityAttribute,
+	"attributes": searchEntityAttribute,
+	"attr":       searchEntityAttribute,
+	"run":        searchEntityAttribute,
+}
+
+var (
+	stringSearchAttributes = map[string]bool{
+		"run_id":        true,
+		"run_name":      true,
+		"status":        true,
+		"user_id":       true,
+		"artifact_uri":  true,
+		"experiment_id": true,
+	}
+	numericSearchAttributes = map[string]bool{
+		"start_time": true,
+		"end_time":   true,
+	}
+	searchAttributeAliases = map[string]string{
+		"created": "start_time",
+		"Created": "start_time",
+	}
+	numericComparators = map[string]bool{
+		"=": true, "!=": true, ">": true, ">=": true, "<": true, "<=": true,
+	}
+	stringComparators = map[string]bool{
+		"=": true, "!=": true, "LIKE": true, "ILIKE": true,
+	}
+	stringAttributeComparators = map[string]bool{
+		"=": true, "!=": true, "LIKE": true, "ILIKE": true, "IN": true, "NOT IN": true,
+	}
+)
+
+// runFields holds the values of a run that search filters and orderings can refer to.
+type runFields struct {
+	stringAttrs  map[string]string
+	numericAttrs map[string]int64
+	metrics      map[string]float64
+	params       map[string]string
+	tags         map[string]string
+}
+
+type searchClause struct {
+	entity     string
+	key        string
+	comparator string
+	// Set for string comparisons.
+	strValue string
+	// Set for IN and NOT IN.
+	strValues []string
+	// Set for numeric comparisons.
+	numValue float64
+	// Set for LIKE and ILIKE.
+	pattern *regexp.Regexp
+}
+
+// runFilter is a parsed search filter. A run matches if it matches all of the clauses.
+type runFilter []searchClause
+
+func (f runFilter) matches(run *runFields) bool {
+	for i := range f {
+		if !f[i].matches(run) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *searchClause) matches(run *runFields) bool {
+	switch c.entity {
+	case searchEntityMetric:
+		val, ok := run.metrics[c.key]
+		return ok && compareNumbers(val, c.comparator, c.numValue)
+	case searchEntityParam:
+		val, ok := run.params[c.key]
+		return ok && c.compareString(val)
+	case searchEntityTag:
+		val, ok := run.tags[c.key]
+		return ok && c.compareString(val)
+	case searchEntityAttribute:
+		if numericSearchAttributes[c.key] {
+			val, ok := run.numericAttrs[c.key]
+			return ok && compareNumbers(float64(val), c.comparator, c.numValue)
+		}
+		val, ok := run.stringAttrs[c.key]
+		return ok && c.compareString(val)
+	}
+	return false
+}
+
+func compareNumbers(lhs float64, comparator string, rhs float64) bool {
+	switch comparator {
+	case "=":
+		return lhs == rhs
+	case "!=":
+		return lhs != rhs
+	case ">":
+		return lhs > rhs
+	case ">=":
+		return lhs >= rhs
+	case "<":
+		return lhs < rhs
+	case "<=":
+		return lhs <= rhs
+	}
+	return false
+}
+
+func (c *searchClause) compareString(lhs string) bool {
+	switch c.comparator {
+	case "=":
+		return lhs == c.strValue
+	case "!=":
+		return lhs != c.strValue
+	case "LIKE", "ILIKE":
+		return c.pattern.MatchString(lhs)
+	case "IN", "NOT IN":
+		in := false
+		for _, v := range c.strValues {
+			if lhs == v {
+				in = true
+				break
+			}
+		}
+		return in == (c.comparator == "IN")
+	}
+	return false
+}
+
+// likePattern converts a SQL LIKE pattern to an equivalent regexp.
+func likePattern(pattern string, caseInsensitive bool) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	if caseInsensitive {
+		sb.WriteString("(?i)")
+	}
+	sb.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+type searchTokenKind int
+
+const (
+	searchTokenIdentifier searchTokenKind = iota
+	searchTokenString
+	searchTokenNumber
+	searchTokenOperator
+	searchTokenLeftParen
+	searchTokenRightParen
+	searchTokenComma
+)
+
+type searchToken struct {
+	kind searchTokenKind
+	text string
+}
+
+func isIdentifierRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+// readQuoted reads a string enclosed in s[0], returning the unquoted string
+// and the number of bytes consumed.
+func readQuoted(s string) (string, int, error) {
+	quote := s[0]
+	end := strings.IndexByte(s[1:], quote)
+	if end < 0 {
+		return "", 0, fmt.Errorf("unterminated quote in %q", s)
+	}
+	return s[1 : end+1], end + 2, nil
+}
+
+func tokenizeSearch(s string) ([]searchToken, error) {
+	tokens := make([]searchToken, 0)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, searchToken{searchTokenLeftParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, searchToken{searchTokenRightParen, ")"})
+			i++
+		case c == ',':
+			tokens = append(tokens, searchToken{searchTokenComma, ","})
+			i++
+		case c == '\'' || c == '"':
+			str, n, err := readQuoted(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, searchToken{searchTokenString, str})
+			i += n
+		case c == '`':
+			// Backticks can only be used to quote keys, e.g. metrics.`my metric`.
+			str, n, err := readQuoted(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, searchToken{searchTokenIdentifier, str})
+			i += n
+		case c == '=' || c == '!' || c == '<' || c == '>':
+			n := 1
+			if i+1 < len(s) && (s[i+1] == '=' || (c == '<' && s[i+1] == '>')) {
+				n = 2
+			}
+			op := s[i : i+n]
+			if op == "!" {
+				return nil, fmt.Errorf("invalid operator %q", op)
+			}
+			if op == "<>" {
+				op = "!="
+			}
+			tokens = append(tokens, searchToken{searchTokenOperator, op})
+			i += n
+		case c == '-' || c == '+' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(s) && (isIdentifierRune(rune(s[j])) || ((s[j] == '-' || s[j] == '+') && (s[j-1] == 'e' || s[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, searchToken{searchTokenNumber, s[i:j]})
+			i = j
+		default:
+			j := i
+			for j < len(s) {
+				r := rune(s[j])
+				if !isIdentifierRune(r) && r < 0x80 {
+					break
+				}
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			ident := s[i:j]
+			// Handle quoted keys, e.g. tags."my-tag" or metrics.`my metric`.
+			if ident[len(ident)-1] == '.' && j < len(s) && (s[j] == '"' || s[j] == '`') {
+				key, n, err := readQuoted(s[j:])
+				if err != nil {
+					return nil, err
+				}
+				ident += key
+				j += n
+			}
+			tokens = append(tokens, searchToken{searchTokenIdentifier, ident})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// parseSearchIdentifier splits an identifier like "metrics.loss" into an entity type and key.
+// Identifiers without an entity type are attributes.
+func parseSearchIdentifier(ident string) (string, string, error) {
+	entity := searchEntityAttribute
+	key := ident
+	if prefix, rest, found := strings.Cut(ident, "."); found {
+		var ok bool
+		entity, ok = searchEntityAliases[strings.ToLower(prefix)]
+		if !ok {
+			return "", "", fmt.Errorf("invalid entity type %q in identifier %q. "+
+				"Valid values are metrics, params, tags and attributes", prefix, ident)
+		}
+		key = rest
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("missing key in identifier %q", ident)
+	}
+	if entity == searchEntityAttribute {
+		if alias, ok := searchAttributeAliases[key]; ok {
+			key = alias
+		}
+		if !stringSearchAttributes[key] && !numericSearchAttributes[key] {
+			return "", "", fmt.Errorf("invalid attribute key %q", key)
+		}
+	}
+	return entity, key, nil
+}
+
+// newRunFilter parses an MLFlow search filter, e.g.
+// "metrics.loss < 0.1 AND params.model = 'cnn' AND attributes.status = 'FINISHED'".
+func newRunFilter(filter string) (runFilter, error) {
+	tokens, err := tokenizeSearch(filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", filter, err)
+	}
+	p := searchParser{tokens: tokens}
+	clauses := make(runFilter, 0)
+	for !p.done() {
+		if len(clauses) > 0 {
+			if tok, ok := p.next(); !ok || tok.kind != searchTokenIdentifier || !strings.EqualFold(tok.text, "AND") {
+				return nil, fmt.Errorf("invalid filter %q: expected AND between clauses, got %q", filter, tok.text)
+			}
+		}
+		clause, err := p.parseClause()
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %w", filter, err)
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses, nil
+}
+
+type searchParser struct {
+	tokens []searchToken
+	pos    int
+}
+
+func (p *searchParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *searchParser) next() (searchToken, bool) {
+	if p.done() {
+		return searchToken{}, false
+	}
+	p.pos++
+	return p.tokens[p.pos-1], true
+}
+
+func (p *searchParser) parseComparator() (string, error) {
+	tok, ok := p.next()
+	if !ok {
+		return "", fmt.Errorf("expected comparator")
+	}
+	if tok.kind == searchTokenOperator {
+		return tok.text, nil
+	}
+	if tok.kind != searchTokenIdentifier {
+		return "", fmt.Errorf("expected comparator, got %q", tok.text)
+	}
+	comparator := strings.ToUpper(tok.text)
+	if comparator == "NOT" {
+		if next, ok := p.next(); ok && strings.EqualFold(next.text, "IN") {
+			return "NOT IN", nil
+		}
+		return "", fmt.Errorf("expected IN after NOT")
+	}
+	if comparator != "LIKE" && comparator != "ILIKE" && comparator != "IN" {
+		return "", fmt.Errorf("invalid comparator %q", tok.text)
+	}
+	return comparator, nil
+}
+
+func (p *searchParser) parseClause() (searchClause, error) {
+	var clause searchClause
+	tok, ok := p.next()
+	if !ok || tok.kind != searchTokenIdentifier {
+		return clause, fmt.Errorf("expected identifier, got %q", tok.text)
+	}
+	entity, key, err := parseSearchIdentifier(tok.text)
+	if err != nil {
+		return clause, err
+	}
+	clause.entity, clause.key = entity, key
+	if clause.comparator, err = p.parseComparator(); err != nil {
+		return clause, err
+	}
+
+	numeric := entity == searchEntityMetric || (entity == searchEntityAttribute && numericSearchAttributes[key])
+	validComparators := stringComparators
+	if numeric {
+		validComparators = numericComparators
+	} else if entity == searchEntityAttribute {
+		validComparators = stringAttributeComparators
+	}
+	if !validComparators[clause.comparator] {
+		return clause, fmt.Errorf("invalid comparator %q for %s %q", clause.comparator, entity, key)
+	}
+
+	if clause.comparator == "IN" || clause.comparator == "NOT IN" {
+		clause.strValues, err = p.parseValueList()
+		return clause, err
+	}
+
+	tok, ok = p.next()
+	if !ok {
+		return clause, fmt.Errorf("expected value after %q", clause.comparator)
+	}
+	if numeric {
+		if tok.kind != searchTokenNumber {
+			return clause, fmt.Errorf("expected numeric value for %s %q, got %q", entity, key, tok.text)
+		}
+		clause.numValue, err = strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return clause, fmt.Errorf("invalid numeric value %q: %w", tok.text, err)
+		}
+		return clause, nil
+	}
+	if tok.kind != searchTokenString {
+		return clause, fmt.Errorf("expected quoted string value for %s %q, got %q", entity, key, tok.text)
+	}
+	clause.strValue = tok.text
+	if clause.comparator == "LIKE" || clause.comparator == "ILIKE" {
+		clause.pattern, err = likePattern(tok.text, clause.comparator == "ILIKE")
+	}
+	return clause, err
+}
+
+func (p *searchParser) parseValueList() ([]string, error) {
+	if tok, ok := p.next(); !ok || tok.kind != searchTokenLeftParen {
+		return nil, fmt.Errorf("expected ( after IN")
+	}
+	values := make([]string, 0)
+	for {
+		tok, ok := p.next()
+		if !ok || tok.kind != searchTokenString {
+			return nil, fmt.Errorf("expected quoted string in value list, got %q", tok.text)
+		}
+		values = append(values, tok.text)
+		tok, ok = p.next()
+		if !ok {
+			return nil, fmt.Errorf("unterminated value list")
+		}
+		if tok.kind == searchTokenRightParen {
+			return values, nil
+		}
+		if tok.kind != searchTokenComma {
+			return nil, fmt.Errorf("expected , or ) in value list, got %q", tok.text)
+		}
+	}
+}
+
+type runOrder struct {
+	entity    string
+	key       string
+	ascending bool
+}
+
+// parseOrderBy parses order by clauses like "metrics.loss ASC" or "attributes.start_time DESC".
+func parseOrderBy(orderBy []string) ([]runOrder, error) {
+	orders := make([]runOrder, 0, len(orderBy))
+	for _, clause := range orderBy {
+		tokens, err := tokenizeSearch(clause)
+		if err != nil {
+			return nil, fmt.Errorf("invalid order by clause %q: %w", clause, err)
+		}
+		if len(tokens) == 0 || len(tokens) > 2 || tokens[0].kind != searchTokenIdentifier {
+			return nil, fmt.Errorf("invalid order by clause %q", clause)
+		}
+		entity, key, err := parseSearchIdentifier(tokens[0].text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid order by clause %q: %w", clause, err)
+		}
+		order := runOrder{entity: entity, key: key, ascending: true}
+		if len(tokens) == 2 {
+			switch strings.ToUpper(tokens[1].text) {
+			case "ASC":
+			case "DESC":
+				order.ascending = false
+			default:
+				return nil, fmt.Errorf("invalid order by clause %q: expected ASC or DESC, got %q", clause, tokens[1].text)
+			}
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// sortValue returns the value of the field referred to by o,
+// and whether it is set. Exactly one of the string or float64 is meaningful.
+func (o *runOrder) sortValue(run *runFields) (string, float64, bool) {
+	switch o.entity {
+	case searchEntityMetric:
+		val, ok := run.metrics[o.key]
+		return "", val, ok && !math.IsNaN(val)
+	case searchEntityParam:
+		val, ok := run.params[o.key]
+		return val, 0, ok
+	case searchEntityTag:
+		val, ok := run.tags[o.key]
+		return val, 0, ok
+	case searchEntityAttribute:
+		if numericSearchAttributes[o.key] {
+			val, ok := run.numericAttrs[o.key]
+			return "", float64(val), ok
+		}
+		val, ok := run.stringAttrs[o.key]
+		return val, 0, ok
+	}
+	return "", 0, false
+}
+
+// compare returns a negative number if a sorts before b, positive if after, and 0 if tied.
+// Like MLFlow, unset values are always sorted last.
+func (o *runOrder) compare(a, b *runFields) int {
+	aStr, aNum, aOK := o.sortValue(a)
+	bStr, bNum, bOK := o.sortValue(b)
+	if !aOK || !bOK {
+		if aOK == bOK {
+			return 0
+		}
+		if aOK {
+			return -1
+		}
+		return 1
+	}
+	cmp := strings.Compare(aStr, bStr)
+	if aNum < bNum {
+		cmp = -1
+	} else if aNum > bNum {
+		cmp = 1
+	}
+	if !o.ascending {
+		cmp = -cmp
+	}
+	return cmp
+}
+
+// sortRuns sorts runs by orders. Ties are broken by start time descending, then run ID,
+// which is also the default order.
+func sortRuns(runs []Run, fields []*runFields, orders []runOrder) {
+	orders = append(orders,
+		runOrder{entity: searchEntityAttribute, key: "start_time", ascending: false},
+		runOrder{entity: searchEntityAttribute, key: "run_id", ascending: true})
+	sort.Sort(runSorter{runs, fields, orders})
+}
+
+type runSorter struct {
+	runs   []Run
+	fields []*runFields
+	orders []runOrder
+}
+
+func (s runSorter) Len() int {
+	return len(s.runs)
+}
+
+func (s runSorter) Less(i, j int) bool {
+	for k := range s.orders {
+		if cmp := s.orders[k].compare(s.fields[i], s.fields[j]); cmp != 0 {
+			return cmp < 0
+		}
+	}
+	return false
+}
+
+func (s runSorter) Swap(i, j int) {
+	s.runs[i], s.runs[j] = s.runs[j], s.runs[i]
+	s.fields[i], s.fields[j] = s.fields[j], s.fields[i]
+}
+
+// FilterRuns applies the filter, ordering and pagination of [Tracking.SearchRuns] to runs,
+// along with the view type set with [WithViewType]. It is for implementations of [Tracking]
+// that keep their runs in memory, like the one in package mlflowtest.
+// Returns (matching runs, next page token, error)
+func FilterRuns(runs []Run, filter string, orderBy []string, pageToken string, opts ...SearchOption) ([]Run, string, error) {
+	return filterRuns(context.Background(), runs, filter, orderBy, pageToken, opts...)
+}
+
+// filterRuns implements [FilterRuns], stopping if ctx is done.
+func filterRuns(ctx context.Context, runs []Run, filter string, orderBy []string, pageToken string, opts ...SearchOption) ([]Run, string, error) {
+	options := newSearchOptions(opts)
+	runFilter, err := newRunFilter(filter)
+	if err != nil {
+		return nil, "", newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "%v", err)
+	}
+	orders, err := parseOrderBy(orderBy)
+	if err != nil {
+		return nil, "", newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "%v", err)
+	}
+	matched := make([]Run, 0)
+	matchedFields := make([]*runFields, 0)
+	for _, run := range runs {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		info, err := run.Info()
+		if err != nil {
+			return nil, "", err
+		}
+		if !options.viewType.includes(info.LifecycleStage) {
+			continue
+		}
+		fields, err := newRunFields(run, info)
+		if err != nil {
+			return nil, "", err
+		}
+		if runFilter.matches(fields) {
+			matched = append(matched, run)
+			matchedFields = append(matchedFields, fields)
+		}
+	}
+	sortRuns(matched, matchedFields, orders)
+	start, end, nextPageToken, err := paginate(len(matched), pageToken, options.maxResults)
+	if err != nil {
+		return nil, "", newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "%v", err)
+	}
+	return matched[start:end], nextPageToken, nil
+}
+
+// newRunFields gets the fields of run that can be searched, using only the [Run] interface.
+func newRunFields(run Run, info RunInfo) (*runFields, error) {
+	fields := &runFields{
+		stringAttrs: map[string]string{
+			"run_id":        info.ID,
+			"run_name":      info.Name,
+			"status":        info.Status.String(),
+			"user_id":       info.UserID,
+			"artifact_uri":  info.ArtifactURI,
+			"experiment_id": info.ExperimentID,
+		},
+		numericAttrs: map[string]int64{"start_time": 0, "end_time": 0},
+		metrics:      make(map[string]float64),
+		params:       make(map[string]string),
+		tags:         make(map[string]string),
+	}
+	if !info.StartTime.IsZero() {
+		fields.numericAttrs["start_time"] = info.StartTime.UnixMilli()
+	}
+	if !info.EndTime.IsZero() {
+		fields.numericAttrs["end_time"] = info.EndTime.UnixMilli()
+	}
+	params, err := run.Params()
+	if err != nil {
+		return nil, err
+	}
+	for _, param := range params {
+		fields.params[param.Key] = param.Val
+	}
+	tags, err := run.Tags()
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		fields.tags[tag.Key] = tag.Val
+	}
+	metrics, err := run.GetLatestMetrics()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range metrics {
+		fields.metrics[m.Key] = m.Val
+	}
+	return fields, nil
+}
+
+// Page tokens are compatible with the Python client's FileStore.
+// https://github.com/mlflow/mlflow/blob/8cd2eb0f7975decefb88af60ac5cc4f968458ab3/mlflow/utils/search_utils.py#L1012
+type pageToken struct {
+	Offset int `json:"offset"`
+}
+
+func createPageToken(offset int) string {
+	tokenJSON, _ := json.Marshal(pageToken{Offset: offset})
+	return base64.StdEncoding.EncodeToString(tokenJSON)
+}
+
+func parsePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	tokenJSON, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid page token %q: %w", token, err)
+	}
+	var parsed pageToken
+	if err = json.Unmarshal(tokenJSON, &parsed); err != nil {
+		return 0, fmt.Errorf("invalid page token %q: %w", token, err)
+	}
+	if parsed.Offset < 0 {
+		return 0, fmt.Errorf("invalid page token %q: negative offset", token)
+	}
+	return parsed.Offset, nil
+}
+
+// paginate returns the page of length at most maxResults starting at the offset in token,
+// along with the token for the next page, which is empty if there are no more results.
+func paginate(numResults int, token string, maxResults int) (int, int, string, error) {
+	if maxResults <= 0 || maxResults > maxSearchRunsMaxResults {
+		return 0, 0, "", fmt.Errorf("invalid max results %d, must be between 1 and %d", maxResults, maxSearchRunsMaxResults)
+	}
+	start, err := parsePageToken(token)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	if start > numResults {
+		start = numResults
+	}
+	end := start + maxResults
+	if end >= numResults {
+		return start, numResults, "", nil
+	}
+	return start, end, createPageToken(end), nil
+}
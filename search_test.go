@@ -0,0 +1,165 @@
+package mlflow
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunFilter(t *testing.T) {
+	run := &runFields{
+		stringAttrs: map[string]string{
+			"run_id":   "abc123",
+			"run_name": "my-run",
+			"status":   "FINISHED",
+		},
+		numericAttrs: map[string]int64{"start_time": 1000, "end_time": 2000},
+		metrics:      map[string]float64{"loss": 0.5, "val acc": 0.9},
+		params:       map[string]string{"model": "CNN"},
+		tags:         map[string]string{"mlflow.user": "alice", "extra-key": "x"},
+	}
+	for _, tc := range []struct {
+		filter string
+		want   bool
+	}{
+		{"", true},
+		{"metrics.loss < 1", true},
+		{"metrics.loss >= 0.5", true},
+		{"metrics.loss > 0.5", false},
+		{"metric.`val acc` = 0.9", true},
+		{"metrics.missing != 1", false},
+		{"params.model = 'CNN'", true},
+		{"params.model != 'CNN'", false},
+		{"params.model LIKE 'C%'", true},
+		{"params.model LIKE 'c%'", false},
+		{"params.model ILIKE 'c_n'", true},
+		{"tags.mlflow.user = 'alice'", true},
+		{`tags."extra-key" = "x"`, true},
+		{"tags.`extra-key` = 'y'", false},
+		{"attributes.status = 'FINISHED'", true},
+		{"status != 'FINISHED'", false},
+		{"attributes.run_name LIKE '%run'", true},
+		{"attributes.run_id IN ('abc123', 'def456')", true},
+		{"attributes.run_id NOT IN ('abc123')", false},
+		{"attributes.start_time > 500 and attributes.end_time <= 2000", true},
+		{"created < 1000", false},
+		{"metrics.loss < 1 AND params.model = 'CNN' AND tags.mlflow.user = 'bob'", false},
+	} {
+		t.Run(tc.filter, func(t *testing.T) {
+			f, err := newRunFilter(tc.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, f.matches(run))
+		})
+	}
+}
+
+func TestRunFilterInvalid(t *testing.T) {
+	for _, filter := range []string{
+		"metrics.loss",
+		"metrics.loss < 'a'",
+		"metrics.loss LIKE '1'",
+		"params.model < 1",
+		"params.model = CNN",
+		"params.model IN ('a')",
+		"foo.bar = 'a'",
+		"attributes.foo = 'a'",
+		"params.a = 'a' params.b = 'b'",
+		"params.a = 'a' OR params.b = 'b'",
+		"params.a = 'a",
+	} {
+		t.Run(filter, func(t *testing.T) {
+			_, err := newRunFilter(filter)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFileStoreSearchRunsFilter(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run0, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	require.NoError(t, run0.LogMetric("loss", 2, 0))
+	require.NoError(t, run0.LogMetric("loss", 0.1, 1))
+	require.NoError(t, run0.LogParam("model", "cnn"))
+	run1, err := exp.CreateRun("run1")
+	require.NoError(t, err)
+	require.NoError(t, run1.LogMetric("loss", 0.5, 0))
+	require.NoError(t, run1.LogParam("model", "mlp"))
+	require.NoError(t, run1.End())
+
+	runs, _, err := fs.SearchRuns([]string{exp.ID()}, "metrics.loss < 0.2", nil, "")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, run0.ID(), runs[0].ID())
+
+	runs, _, err = fs.SearchRuns([]string{exp.ID()}, "params.model LIKE '%p' AND attributes.status = 'FINISHED'", nil, "")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, run1.ID(), runs[0].ID())
+
+	_, _, err = fs.SearchRuns([]string{exp.ID()}, "metrics.loss < 'x'", nil, "")
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+}
+
+func TestFileStoreSearchRunsOrderAndPagination(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	losses := []float64{0.3, 0.1, 0.2}
+	ids := make([]string, len(losses))
+	for i, loss := range losses {
+		run, err := exp.CreateRun(fmt.Sprintf("run%d", i))
+		require.NoError(t, err)
+		require.NoError(t, run.LogMetric("loss", loss, 0))
+		ids[i] = run.ID()
+	}
+	noMetric, err := exp.CreateRun("no-metric")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		orderBy []string
+		want    []string
+	}{
+		{[]string{"metrics.loss ASC"}, []string{ids[1], ids[2], ids[0], noMetric.ID()}},
+		{[]string{"metrics.loss DESC"}, []string{ids[0], ids[2], ids[1], noMetric.ID()}},
+		{[]string{"attributes.run_name"}, []string{noMetric.ID(), ids[0], ids[1], ids[2]}},
+	} {
+		t.Run(tc.orderBy[0], func(t *testing.T) {
+			got := make([]string, 0)
+			pageToken := ""
+			for {
+				runs, nextPageToken, err := fs.SearchRuns([]string{exp.ID()}, "", tc.orderBy, pageToken, WithMaxResults(3))
+				require.NoError(t, err)
+				for _, run := range runs {
+					got = append(got, run.ID())
+				}
+				if nextPageToken == "" {
+					break
+				}
+				pageToken = nextPageToken
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	_, _, err = fs.SearchRuns([]string{exp.ID()}, "", []string{"metrics.loss UP"}, "")
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+	_, _, err = fs.SearchRuns([]string{exp.ID()}, "", nil, "not a token")
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+}
+
+func TestPageToken(t *testing.T) {
+	// Generated by the Python client.
+	offset, err := parsePageToken("eyJvZmZzZXQiOiAxMDAwfQ==")
+	require.NoError(t, err)
+	assert.Equal(t, 1000, offset)
+	offset, err = parsePageToken(createPageToken(5))
+	require.NoError(t, err)
+	assert.Equal(t, 5, offset)
+}
@@ -0,0 +1,803 @@
+package mlflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Astera-org/mlflow-go/protos"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// serverAPIPrefix is the path under which the tracking API is served, the same as for the Python server.
+const serverAPIPrefix = "/api/2.0/mlflow/"
+
+type serverEndpoint struct {
+	methods []string
+	serve   func(t Tracking, r *http.Request) (interface{}, error)
+}
+
+// serverEndpoints maps paths relative to serverAPIPrefix to their implementations.
+// The endpoints are defined in protos/service.proto.
+var serverEndpoints = map[string]serverEndpoint{
+	"experiments/create":                {[]string{http.MethodPost}, serveCreateExperiment},
+	"experiments/search":                {[]string{http.MethodGet, http.MethodPost}, serveSearchExperiments},
+	"experiments/get":                   {[]string{http.MethodGet}, serveGetExperiment},
+	"experiments/get-by-name":           {[]string{http.MethodGet}, serveGetExperimentByName},
+	"experiments/delete":                {[]string{http.MethodPost}, serveDeleteExperiment},
+	"experiments/restore":               {[]string{http.MethodPost}, serveRestoreExperiment},
+	"experiments/update":                {[]string{http.MethodPost}, serveUpdateExperiment},
+	"experiments/set-experiment-tag":    {[]string{http.MethodPost}, serveSetExperimentTag},
+	"experiments/delete-experiment-tag": {[]string{http.MethodPost}, serveDeleteExperimentTag},
+	"runs/create":                       {[]string{http.MethodPost}, serveCreateRun},
+	"runs/update":                       {[]string{http.MethodPost}, serveUpdateRun},
+	"runs/delete":                       {[]string{http.MethodPost}, serveDeleteRun},
+	"runs/restore":                      {[]string{http.MethodPost}, serveRestoreRun},
+	"runs/get":                          {[]string{http.MethodGet}, serveGetRun},
+	"runs/search":                       {[]string{http.MethodPost}, serveSearchRuns},
+	"runs/log-metric":                   {[]string{http.MethodPost}, serveLogMetric},
+	"runs/log-parameter":                {[]string{http.MethodPost}, serveLogParam},
+	"runs/log-batch":                    {[]string{http.MethodPost}, serveLogBatch},
+	"runs/set-tag":                      {[]string{http.MethodPost}, serveSetTag},
+	"runs/delete-tag":                   {[]string{http.MethodPost}, serveDeleteTag},
+	"metrics/get-history":               {[]string{http.MethodGet}, serveGetMetricHistory},
+	"artifacts/list":                    {[]string{http.MethodGet}, serveListArtifacts},
+}
+
+type trackingHandler struct {
+	tracking Tracking
+}
+
+// NewHandler returns an http.Handler that serves the MLFlow tracking REST API under /api/2.0/mlflow/,
+// backed by tracking, e.g. a [FileStore]. [RESTStore] and the official MLFlow clients can use it.
+// Requests are handled with the request's context.
+//
+// Searches support the same filters and orderings as [Tracking.SearchRuns], except that experiments
+// can't be filtered or ordered. Artifacts are listed with [Run.ListArtifacts],
+// and clients access artifacts directly rather than through the server.
+func NewHandler(tracking Tracking) http.Handler {
+	return &trackingHandler{tracking: tracking}
+}
+
+func (h *trackingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint, ok := serverEndpoints[strings.TrimPrefix(r.URL.Path, serverAPIPrefix)]
+	if !ok || !strings.HasPrefix(r.URL.Path, serverAPIPrefix) {
+		writeServerError(w, newError(protos.ErrorCode_ENDPOINT_NOT_FOUND, "no endpoint %s", r.URL.Path))
+		return
+	}
+	allowed := false
+	for _, method := range endpoint.methods {
+		allowed = allowed || r.Method == method
+	}
+	if !allowed {
+		w.Header().Set("Allow", strings.Join(endpoint.methods, ", "))
+		writeServerJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error_code": protos.ErrorCode_BAD_REQUEST.String(),
+			"message":    "method " + r.Method + " not allowed for " + r.URL.Path,
+		})
+		return
+	}
+	res, err := endpoint.serve(h.tracking.WithContext(r.Context()), r)
+	if err != nil {
+		writeServerError(w, err)
+		return
+	}
+	writeServerJSON(w, http.StatusOK, res)
+}
+
+// serverErrorStatuses maps error codes to the HTTP statuses that the Python server uses for them.
+// Other codes are reported as internal server errors.
+var serverErrorStatuses = map[string]int{
+	protos.ErrorCode_BAD_REQUEST.String():             http.StatusBadRequest,
+	protos.ErrorCode_MALFORMED_REQUEST.String():       http.StatusBadRequest,
+	protos.ErrorCode_INVALID_PARAMETER_VALUE.String(): http.StatusBadRequest,
+	protos.ErrorCode_RESOURCE_ALREADY_EXISTS.String(): http.StatusBadRequest,
+	protos.ErrorCode_INVALID_STATE.String():           http.StatusBadRequest,
+	protos.ErrorCode_UNAUTHENTICATED.String():         http.StatusUnauthorized,
+	protos.ErrorCode_PERMISSION_DENIED.String():       http.StatusForbidden,
+	protos.ErrorCode_RESOURCE_DOES_NOT_EXIST.String(): http.StatusNotFound,
+	protos.ErrorCode_ENDPOINT_NOT_FOUND.String():      http.StatusNotFound,
+	protos.ErrorCode_REQUEST_LIMIT_EXCEEDED.String():  http.StatusTooManyRequests,
+	protos.ErrorCode_TEMPORARILY_UNAVAILABLE.String(): http.StatusServiceUnavailable,
+}
+
+func writeServerError(w http.ResponseWriter, err error) {
+	var mlflowErr *Error
+	if !errors.As(err, &mlflowErr) {
+		mlflowErr = newError(protos.ErrorCode_INTERNAL_ERROR, "%v", err)
+	}
+	status, ok := serverErrorStatuses[mlflowErr.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	writeServerJSON(w, status, map[string]string{"error_code": mlflowErr.Code, "message": mlflowErr.Message})
+}
+
+// writeServerJSON writes res as the response. Protobuf messages are written as protobuf JSON, like by the
+// Python server, so that e.g. NaN metric values can be encoded.
+func writeServerJSON(w http.ResponseWriter, status int, res interface{}) {
+	var body []byte
+	var err error
+	if msg, ok := res.(proto.Message); ok {
+		body, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	} else {
+		body, err = json.Marshal(res)
+	}
+	if err != nil {
+		status = http.StatusInternalServerError
+		body, _ = json.Marshal(map[string]string{
+			"error_code": protos.ErrorCode_INTERNAL_ERROR.String(),
+			"message":    "error marshalling response: " + err.Error(),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// decodeServerRequest parses the query of a GET request, or the JSON body of other requests, into req.
+// Bodies are parsed as protobuf JSON, so 64-bit integers may be strings and enums may be names or numbers.
+func decodeServerRequest(r *http.Request, req proto.Message) error {
+	var body []byte
+	if r.Method == http.MethodGet {
+		// Convert the query to JSON so that it can be parsed the same way.
+		fields := req.ProtoReflect().Descriptor().Fields()
+		values := make(map[string]interface{})
+		for key, vals := range r.URL.Query() {
+			field := fields.ByName(protoreflect.Name(key))
+			switch {
+			case field == nil:
+				continue
+			case field.IsList():
+				values[key] = vals
+			case field.Kind() == protoreflect.BoolKind:
+				values[key] = vals[0] == "true"
+			default:
+				values[key] = vals[0]
+			}
+		}
+		var err error
+		if body, err = json.Marshal(values); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return newError(protos.ErrorCode_MALFORMED_REQUEST, "error reading request body: %v", err)
+		}
+		if len(bytes.TrimSpace(body)) == 0 {
+			body = []byte("{}")
+		}
+	}
+	if err := (protojson.UnmarshalOptions{AllowPartial: true, DiscardUnknown: true}).Unmarshal(body, req); err != nil {
+		return newError(protos.ErrorCode_MALFORMED_REQUEST, "error parsing request: %v", err)
+	}
+	return nil
+}
+
+// requireParam returns the value of a required request parameter.
+// If there is more than one value, e.g. for deprecated aliases like run_uuid, the first that is set is used.
+func requireParam(name string, values ...*string) (string, error) {
+	for _, val := range values {
+		if val != nil && *val != "" {
+			return *val, nil
+		}
+	}
+	return "", newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "missing value for required parameter '%s'", name)
+}
+
+func experimentToProto(exp Experiment) (*protos.Experiment, error) {
+	info, err := exp.Info()
+	if err != nil {
+		return nil, err
+	}
+	res := &protos.Experiment{
+		ExperimentId:     proto.String(info.ID),
+		Name:             proto.String(info.Name),
+		ArtifactLocation: proto.String(info.ArtifactLocation),
+		LifecycleStage:   proto.String(info.LifecycleStage),
+	}
+	if !info.CreationTime.IsZero() {
+		res.CreationTime = proto.Int64(info.CreationTime.UnixMilli())
+	}
+	if !info.LastUpdateTime.IsZero() {
+		res.LastUpdateTime = proto.Int64(info.LastUpdateTime.UnixMilli())
+	}
+	for _, tag := range info.Tags {
+		res.Tags = append(res.Tags, &protos.ExperimentTag{Key: proto.String(tag.Key), Value: proto.String(tag.Val)})
+	}
+	return res, nil
+}
+
+func runInfoToProto(info RunInfo) *protos.RunInfo {
+	res := &protos.RunInfo{
+		RunId:          proto.String(info.ID),
+		RunUuid:        proto.String(info.ID),
+		RunName:        proto.String(info.Name),
+		ExperimentId:   proto.String(info.ExperimentID),
+		UserId:         proto.String(info.UserID),
+		Status:         protos.RunStatus(info.Status).Enum(),
+		StartTime:      proto.Int64(info.StartTime.UnixMilli()),
+		ArtifactUri:    proto.String(info.ArtifactURI),
+		LifecycleStage: proto.String(info.LifecycleStage),
+	}
+	if !info.EndTime.IsZero() {
+		res.EndTime = proto.Int64(info.EndTime.UnixMilli())
+	}
+	return res
+}
+
+func loggedMetricToProto(m LoggedMetric) *protos.Metric {
+	return &protos.Metric{
+		Key:       proto.String(m.Key),
+		Value:     proto.Float64(m.Val),
+		Step:      proto.Int64(m.Step),
+		Timestamp: proto.Int64(m.Timestamp.UnixMilli()),
+	}
+}
+
+func runToProto(run Run) (*protos.Run, error) {
+	info, err := run.Info()
+	if err != nil {
+		return nil, err
+	}
+	params, err := run.Params()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := run.Tags()
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := run.GetLatestMetrics()
+	if err != nil {
+		return nil, err
+	}
+	data := &protos.RunData{}
+	for _, m := range metrics {
+		data.Metrics = append(data.Metrics, loggedMetricToProto(m))
+	}
+	for _, param := range params {
+		data.Params = append(data.Params, &protos.Param{Key: proto.String(param.Key), Value: proto.String(param.Val)})
+	}
+	for _, tag := range tags {
+		data.Tags = append(data.Tags, &protos.RunTag{Key: proto.String(tag.Key), Value: proto.String(tag.Val)})
+	}
+	return &protos.Run{Info: runInfoToProto(info), Data: data}, nil
+}
+
+func serveCreateExperiment(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.CreateExperiment
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	name, err := requireParam("name", req.Name)
+	if err != nil {
+		return nil, err
+	}
+	exps, err := t.ExperimentsByName(WithViewType(ViewTypeAll))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := exps[name]; ok {
+		return nil, newError(protos.ErrorCode_RESOURCE_ALREADY_EXISTS, "experiment %q already exists", name)
+	}
+	exp, err := t.CreateExperiment(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range req.Tags {
+		if err := exp.SetTag(tag.GetKey(), tag.GetValue()); err != nil {
+			return nil, err
+		}
+	}
+	return &protos.CreateExperiment_Response{ExperimentId: proto.String(exp.ID())}, nil
+}
+
+func serveSearchExperiments(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.SearchExperiments
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	if req.GetFilter() != "" || len(req.OrderBy) > 0 {
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "filter and order_by are not supported for experiments")
+	}
+	viewType := ViewTypeActiveOnly
+	if req.ViewType != nil {
+		viewType = ViewType(req.GetViewType())
+	}
+	maxResults := defaultSearchRunsMaxResults
+	if req.MaxResults != nil {
+		maxResults = int(req.GetMaxResults())
+	}
+	exps, err := t.ExperimentsByName(WithViewType(viewType))
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]Experiment, 0, len(exps))
+	for _, exp := range exps {
+		sorted = append(sorted, exp)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID() < sorted[j].ID() })
+	start, end, nextPageToken, err := paginate(len(sorted), req.GetPageToken(), maxResults)
+	if err != nil {
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "%v", err)
+	}
+	res := &protos.SearchExperiments_Response{Experiments: make([]*protos.Experiment, 0, end-start)}
+	for _, exp := range sorted[start:end] {
+		expProto, err := experimentToProto(exp)
+		if err != nil {
+			return nil, err
+		}
+		res.Experiments = append(res.Experiments, expProto)
+	}
+	if nextPageToken != "" {
+		res.NextPageToken = &nextPageToken
+	}
+	return res, nil
+}
+
+// serverExperiment returns the experiment with the ID in the request.
+func serverExperiment(t Tracking, id *string) (Experiment, error) {
+	expID, err := requireParam("experiment_id", id)
+	if err != nil {
+		return nil, err
+	}
+	return t.GetExperiment(expID)
+}
+
+func serveGetExperiment(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.GetExperiment
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	exp, err := serverExperiment(t, req.ExperimentId)
+	if err != nil {
+		return nil, err
+	}
+	expProto, err := experimentToProto(exp)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.GetExperiment_Response{Experiment: expProto}, nil
+}
+
+func serveGetExperimentByName(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.GetExperimentByName
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	name, err := requireParam("experiment_name", req.ExperimentName)
+	if err != nil {
+		return nil, err
+	}
+	exps, err := t.ExperimentsByName(WithViewType(ViewTypeAll))
+	if err != nil {
+		return nil, err
+	}
+	exp, ok := exps[name]
+	if !ok {
+		return nil, newError(protos.ErrorCode_RESOURCE_DOES_NOT_EXIST, "could not find experiment with name '%s'", name)
+	}
+	expProto, err := experimentToProto(exp)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.GetExperimentByName_Response{Experiment: expProto}, nil
+}
+
+func serveDeleteExperiment(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.DeleteExperiment
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	exp, err := serverExperiment(t, req.ExperimentId)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.DeleteExperiment_Response{}, exp.Delete()
+}
+
+func serveRestoreExperiment(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.RestoreExperiment
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	exp, err := serverExperiment(t, req.ExperimentId)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.RestoreExperiment_Response{}, exp.Restore()
+}
+
+func serveUpdateExperiment(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.UpdateExperiment
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	exp, err := serverExperiment(t, req.ExperimentId)
+	if err != nil {
+		return nil, err
+	}
+	if req.NewName != nil {
+		if err := exp.SetName(req.GetNewName()); err != nil {
+			return nil, err
+		}
+	}
+	return &protos.UpdateExperiment_Response{}, nil
+}
+
+func serveSetExperimentTag(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.SetExperimentTag
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	exp, err := serverExperiment(t, req.ExperimentId)
+	if err != nil {
+		return nil, err
+	}
+	key, err := requireParam("key", req.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.SetExperimentTag_Response{}, exp.SetTag(key, req.GetValue())
+}
+
+// deleteExperimentTagDescriptor describes the DeleteExperimentTag request, which is not in
+// protos/service.proto, see deleteExperimentTag. It is built at run time so that the request
+// can be parsed by decodeServerRequest like the others.
+var deleteExperimentTagDescriptor = func() protoreflect.MessageDescriptor {
+	stringField := func(name string, number int32) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		}
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("delete_experiment_tag.proto"),
+		Package: proto.String("mlflow"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:  proto.String("DeleteExperimentTag"),
+			Field: []*descriptorpb.FieldDescriptorProto{stringField("experiment_id", 1), stringField("key", 2)},
+		}},
+	}, nil)
+	if err != nil {
+		panic(err)
+	}
+	return file.Messages().Get(0)
+}()
+
+func serveDeleteExperimentTag(t Tracking, r *http.Request) (interface{}, error) {
+	req := dynamicpb.NewMessage(deleteExperimentTagDescriptor)
+	if err := decodeServerRequest(r, req); err != nil {
+		return nil, err
+	}
+	// Unset fields are nil, like for the generated messages.
+	field := func(name protoreflect.Name) *string {
+		fd := deleteExperimentTagDescriptor.Fields().ByName(name)
+		if !req.Has(fd) {
+			return nil
+		}
+		val := req.Get(fd).String()
+		return &val
+	}
+	exp, err := serverExperiment(t, field("experiment_id"))
+	if err != nil {
+		return nil, err
+	}
+	key, err := requireParam("key", field("key"))
+	if err != nil {
+		return nil, err
+	}
+	return struct{}{}, exp.DeleteTag(key)
+}
+
+// serverRun returns the run with the ID in the request.
+func serverRun(t Tracking, runID, runUUID *string) (Run, error) {
+	id, err := requireParam("run_id", runID, runUUID)
+	if err != nil {
+		return nil, err
+	}
+	return t.GetRun(id)
+}
+
+func serveCreateRun(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.CreateRun
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	exp, err := serverExperiment(t, req.ExperimentId)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]Tag, 0, len(req.Tags)+1)
+	hasUser := false
+	for _, tag := range req.Tags {
+		tags = append(tags, Tag{Key: tag.GetKey(), Val: tag.GetValue()})
+		hasUser = hasUser || tag.GetKey() == UserTagKey
+	}
+	if !hasUser && req.GetUserId() != "" {
+		tags = append(tags, Tag{Key: UserTagKey, Val: req.GetUserId()})
+	}
+	opts := []CreateRunOption{WithTags(tags)}
+	if req.StartTime != nil {
+		opts = append(opts, WithStartTime(time.UnixMilli(req.GetStartTime())))
+	}
+	run, err := exp.CreateRun(req.GetRunName(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	runProto, err := runToProto(run)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.CreateRun_Response{Run: runProto}, nil
+}
+
+func serveUpdateRun(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.UpdateRun
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, req.RunUuid)
+	if err != nil {
+		return nil, err
+	}
+	info, err := run.Info()
+	if err != nil {
+		return nil, err
+	}
+	// Runs can only be terminated, see Run.SetTerminated, so other changes are rejected rather than dropped.
+	status := RunStatus(req.GetStatus())
+	switch {
+	case req.Status != nil && !status.IsTerminated() && status != info.Status:
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "can't change the status of run %s to %s", info.ID, status)
+	case req.EndTime != nil && !status.IsTerminated():
+		return nil, newError(protos.ErrorCode_INVALID_PARAMETER_VALUE, "end_time can only be set along with a terminal status")
+	}
+	if req.RunName != nil {
+		if err := run.SetName(req.GetRunName()); err != nil {
+			return nil, err
+		}
+	}
+	if status.IsTerminated() {
+		endTime := time.Now()
+		if req.EndTime != nil {
+			endTime = time.UnixMilli(req.GetEndTime())
+		}
+		if err := run.SetTerminated(status, endTime); err != nil {
+			return nil, err
+		}
+	}
+	if info, err = run.Info(); err != nil {
+		return nil, err
+	}
+	return &protos.UpdateRun_Response{RunInfo: runInfoToProto(info)}, nil
+}
+
+func serveDeleteRun(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.DeleteRun
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.DeleteRun_Response{}, run.Delete()
+}
+
+func serveRestoreRun(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.RestoreRun
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.RestoreRun_Response{}, run.Restore()
+}
+
+func serveGetRun(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.GetRun
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, req.RunUuid)
+	if err != nil {
+		return nil, err
+	}
+	runProto, err := runToProto(run)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.GetRun_Response{Run: runProto}, nil
+}
+
+func serveSearchRuns(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.SearchRuns
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	opts := make([]SearchOption, 0, 2)
+	if req.RunViewType != nil {
+		opts = append(opts, WithViewType(ViewType(req.GetRunViewType())))
+	}
+	if req.MaxResults != nil {
+		opts = append(opts, WithMaxResults(int(req.GetMaxResults())))
+	}
+	runs, nextPageToken, err := t.SearchRuns(req.ExperimentIds, req.GetFilter(), req.OrderBy, req.GetPageToken(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := &protos.SearchRuns_Response{Runs: make([]*protos.Run, len(runs))}
+	for i, run := range runs {
+		if res.Runs[i], err = runToProto(run); err != nil {
+			return nil, err
+		}
+	}
+	if nextPageToken != "" {
+		res.NextPageToken = &nextPageToken
+	}
+	return res, nil
+}
+
+func serveLogMetric(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.LogMetric
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, req.RunUuid)
+	if err != nil {
+		return nil, err
+	}
+	key, err := requireParam("key", req.Key)
+	if err != nil {
+		return nil, err
+	}
+	metric := LoggedMetric{Key: key, Val: req.GetValue(), Step: req.GetStep()}
+	if req.Timestamp != nil {
+		metric.Timestamp = time.UnixMilli(req.GetTimestamp())
+	}
+	return &protos.LogMetric_Response{}, run.LogBatch([]LoggedMetric{metric}, nil, nil)
+}
+
+func serveLogParam(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.LogParam
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, req.RunUuid)
+	if err != nil {
+		return nil, err
+	}
+	key, err := requireParam("key", req.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.LogParam_Response{}, run.LogParam(key, req.GetValue())
+}
+
+func serveLogBatch(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.LogBatch
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, nil)
+	if err != nil {
+		return nil, err
+	}
+	metrics := make([]LoggedMetric, len(req.Metrics))
+	for i, m := range req.Metrics {
+		metrics[i] = LoggedMetric{Key: m.GetKey(), Val: m.GetValue(), Step: m.GetStep(), Timestamp: timeFromMillis(m.GetTimestamp())}
+	}
+	params := make([]Param, len(req.Params))
+	for i, p := range req.Params {
+		params[i] = Param{Key: p.GetKey(), Val: p.GetValue()}
+	}
+	tags := make([]Tag, len(req.Tags))
+	for i, tag := range req.Tags {
+		tags[i] = Tag{Key: tag.GetKey(), Val: tag.GetValue()}
+	}
+	return &protos.LogBatch_Response{}, run.LogBatch(metrics, params, tags)
+}
+
+func serveSetTag(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.SetTag
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, req.RunUuid)
+	if err != nil {
+		return nil, err
+	}
+	key, err := requireParam("key", req.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.SetTag_Response{}, run.SetTag(key, req.GetValue())
+}
+
+func serveDeleteTag(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.DeleteTag
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, nil)
+	if err != nil {
+		return nil, err
+	}
+	key, err := requireParam("key", req.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.DeleteTag_Response{}, run.DeleteTag(key)
+}
+
+func serveGetMetricHistory(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.GetMetricHistory
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, req.RunUuid)
+	if err != nil {
+		return nil, err
+	}
+	key, err := requireParam("metric_key", req.MetricKey)
+	if err != nil {
+		return nil, err
+	}
+	// max_results is ignored, since the page size is up to the Tracking implementation.
+	history, nextPageToken, err := run.GetMetricHistory(key, req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	res := &protos.GetMetricHistory_Response{Metrics: make([]*protos.Metric, len(history))}
+	for i, m := range history {
+		res.Metrics[i] = loggedMetricToProto(m)
+	}
+	if nextPageToken != "" {
+		res.NextPageToken = &nextPageToken
+	}
+	return res, nil
+}
+
+func serveListArtifacts(t Tracking, r *http.Request) (interface{}, error) {
+	var req protos.ListArtifacts
+	if err := decodeServerRequest(r, &req); err != nil {
+		return nil, err
+	}
+	run, err := serverRun(t, req.RunId, req.RunUuid)
+	if err != nil {
+		return nil, err
+	}
+	info, err := run.Info()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := run.ListArtifacts(req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	res := &protos.ListArtifacts_Response{RootUri: proto.String(info.ArtifactURI), Files: make([]*protos.FileInfo, len(entries))}
+	for i, entry := range entries {
+		res.Files[i] = &protos.FileInfo{Path: proto.String(entry.Path), IsDir: proto.Bool(entry.IsDir)}
+		if !entry.IsDir {
+			res.Files[i].FileSize = proto.Int64(entry.Size)
+		}
+	}
+	return res, nil
+}
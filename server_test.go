@@ -0,0 +1,231 @@
+package mlflow
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Astera-org/mlflow-go/protos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func newTestServer(t *testing.T) (*FileStore, Tracking, string) {
+	fs, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	server := httptest.NewServer(NewHandler(fs))
+	t.Cleanup(server.Close)
+	rs, err := NewRESTStore(server.URL, "", WithMaxRetries(0))
+	require.NoError(t, err)
+	return fs, rs, server.URL
+}
+
+func TestHandler(t *testing.T) {
+	fs, rs, _ := newTestServer(t)
+
+	exp, err := rs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	_, err = rs.CreateExperiment("exp0")
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	require.NoError(t, exp.SetTag("team", "vision"))
+	require.NoError(t, exp.SetTag("stale", "x"))
+	require.NoError(t, exp.DeleteTag("stale"))
+	_, err = exp.GetTag("stale")
+	assert.ErrorIs(t, err, ErrNotFound)
+	exps, err := rs.ExperimentsByName()
+	require.NoError(t, err)
+	assert.Contains(t, exps, "exp0")
+	assert.Contains(t, exps, defaultName)
+	_, err = rs.GetExperiment("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	startTime := time.UnixMilli(1700000000000)
+	run, err := exp.CreateRun("run0", WithStartTime(startTime), WithTags([]Tag{{Key: "model", Val: "resnet"}}))
+	require.NoError(t, err)
+	require.NoError(t, run.LogMetric("loss", 2, 0))
+	require.NoError(t, run.LogBatch([]LoggedMetric{{Key: "loss", Val: 1, Step: 1, Timestamp: startTime.Add(time.Second)}},
+		[]Param{{Key: "lr", Val: "0.1"}}, []Tag{{Key: "stage", Val: "train"}}))
+	require.NoError(t, run.LogParam("batch_size", "32"))
+	require.NoError(t, run.DeleteTag("stage"))
+
+	// The run was written to the file store.
+	fileRun, err := fs.GetRun(run.ID())
+	require.NoError(t, err)
+	info, err := fileRun.Info()
+	require.NoError(t, err)
+	assert.Equal(t, "run0", info.Name)
+	assert.True(t, startTime.Equal(info.StartTime))
+	history, _, err := fileRun.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.True(t, startTime.Add(time.Second).Equal(history[1].Timestamp))
+
+	// And can be read back through the server.
+	runs, _, err := rs.SearchRuns([]string{exp.ID()}, "params.lr = '0.1' and tags.model = 'resnet'", nil, "")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, run.ID(), runs[0].ID())
+	_, _, err = rs.SearchRuns([]string{exp.ID()}, "params.lr ==", nil, "")
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+	got, err := rs.GetRun(run.ID())
+	require.NoError(t, err)
+	params, err := got.Params()
+	require.NoError(t, err)
+	assert.Equal(t, []Param{{Key: "batch_size", Val: "32"}, {Key: "lr", Val: "0.1"}}, params)
+	_, err = got.GetTag("stage")
+	assert.ErrorIs(t, err, ErrNotFound)
+	history, _, err = got.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+	latest, err := got.GetLatestMetrics()
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	assert.Equal(t, 1.0, latest[0].Val)
+
+	endTime := startTime.Add(time.Hour)
+	require.NoError(t, run.SetTerminated(RunStatusFailed, endTime))
+	info, err = got.Info()
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusFailed, info.Status)
+	assert.True(t, endTime.Equal(info.EndTime))
+
+	require.NoError(t, run.Delete())
+	runs, _, err = rs.SearchRuns([]string{exp.ID()}, "", nil, "")
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+	require.NoError(t, run.Restore())
+
+	_, err = rs.GetRun("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// Runs with local artifact locations use the run's context for artifacts, like DBFS ones.
+func TestRESTRunLocalArtifactsWithContext(t *testing.T) {
+	_, rs, _ := newTestServer(t)
+	exp, err := rs.GetExperiment("0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	localPath := filepath.Join(t.TempDir(), "model.bin")
+	require.NoError(t, os.WriteFile(localPath, []byte("weights"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, run.WithContext(ctx).LogArtifact(localPath, ""), context.Canceled)
+	require.NoError(t, run.LogArtifact(localPath, ""))
+	_, err = run.WithContext(ctx).OpenArtifact("model.bin")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// The Python client sends protobuf JSON, where 64-bit integers are strings and enums are names.
+func TestHandlerProtoJSON(t *testing.T) {
+	fs, _, url := newTestServer(t)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+
+	post := func(path, body string) *http.Response {
+		res, err := http.Post(url+serverAPIPrefix+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+	res := post("runs/log-metric", `{"run_id": "`+run.ID()+`", "key": "loss", "value": 1.5, "timestamp": "1700000000000", "step": "3"}`)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	history, _, err := run.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	assert.Equal(t, []LoggedMetric{{Key: "loss", Val: 1.5, Step: 3, Timestamp: time.UnixMilli(1700000000000)}}, history)
+
+	res = post("runs/update", `{"run_id": "`+run.ID()+`", "status": "FINISHED", "end_time": "1700000001000"}`)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	info, err := run.Info()
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusFinished, info.Status)
+	// Changes that can't be applied are rejected rather than ignored.
+	for _, body := range []string{
+		`{"run_id": "` + run.ID() + `", "status": "RUNNING"}`,
+		`{"run_id": "` + run.ID() + `", "status": "SCHEDULED", "run_name": "renamed"}`,
+		`{"run_id": "` + run.ID() + `", "end_time": "1700000002000"}`,
+	} {
+		res = post("runs/update", body)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, body)
+	}
+	info, err = run.Info()
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusFinished, info.Status)
+	assert.Equal(t, "run0", info.Name)
+	assert.Equal(t, int64(1700000001000), info.EndTime.UnixMilli())
+
+	res = post("runs/log-metric", `{"key": "loss"}`)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "INVALID_PARAMETER_VALUE")
+
+	// DeleteExperimentTag has no generated proto, but is parsed like the others.
+	res = post("experiments/delete-experiment-tag", `{"key": "team", "unknown": 1}`)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	body, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "INVALID_PARAMETER_VALUE")
+	res = post("experiments/delete-experiment-tag", `{"experiment_id": 1}`)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	body, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "MALFORMED_REQUEST")
+
+	res = post("runs/unknown", `{}`)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	res, err = http.Get(url + serverAPIPrefix + "runs/create")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	localPath := filepath.Join(t.TempDir(), "model.bin")
+	require.NoError(t, os.WriteFile(localPath, []byte("weights"), 0644))
+	require.NoError(t, run.LogArtifact(localPath, "checkpoints"))
+	res, err = http.Get(url + serverAPIPrefix + "artifacts/list?run_id=" + run.ID() + "&path=checkpoints")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var listed protos.ListArtifacts_Response
+	require.NoError(t, protojson.Unmarshal(body, &listed))
+	require.Len(t, listed.Files, 1)
+	assert.Equal(t, "checkpoints/model.bin", listed.Files[0].GetPath())
+	assert.False(t, listed.Files[0].GetIsDir())
+	assert.Equal(t, int64(7), listed.Files[0].GetFileSize())
+}
+
+func TestHandlerNonFiniteMetrics(t *testing.T) {
+	fs, rs, _ := newTestServer(t)
+	exp, err := fs.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	run, err := exp.CreateRun("run0")
+	require.NoError(t, err)
+	require.NoError(t, run.LogMetric("loss", math.NaN(), 0))
+	require.NoError(t, run.LogMetric("grad", math.Inf(1), 0))
+
+	got, err := rs.GetRun(run.ID())
+	require.NoError(t, err)
+	history, _, err := got.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.True(t, math.IsNaN(history[0].Val))
+	latest, err := got.GetLatestMetrics()
+	require.NoError(t, err)
+	assert.Len(t, latest, 2)
+	runs, _, err := rs.SearchRuns([]string{exp.ID()}, "", nil, "")
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
@@ -0,0 +1,316 @@
+package mlflow
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// SourceRunIDTagKey is the tag that [Sync] sets on the runs it creates,
+// with the ID of the run they were copied from.
+const SourceRunIDTagKey = "mlflow_go.source_run_id"
+
+// SyncOptions configures [Sync].
+type SyncOptions struct {
+	// If set, only these experiments are copied. Defaults to all active experiments.
+	ExperimentIDs []string
+}
+
+// SyncResult reports what [Sync] copied.
+type SyncResult struct {
+	// IDs of the runs created in the destination.
+	CreatedRunIDs []string
+	// IDs of the runs in the destination that already existed and were updated.
+	UpdatedRunIDs []string
+	// The number of metric values logged to the destination.
+	MetricsLogged int
+}
+
+// Sync copies experiments and their active runs from src to dst, e.g. from a [FileStore]
+// on a machine without network access to a tracking server. Experiments are matched by name,
+// and are created in dst if needed. Runs are copied with their params, tags, metric histories,
+// status, start and end times, and artifacts. Artifacts can only be copied from local directories.
+//
+// Sync can be repeated to copy what was logged since the last sync. Each copied run is tagged with
+// [SourceRunIDTagKey], and runs that were copied before are updated rather than copied again.
+// Artifacts of an existing run are copied again only once the source run has terminated.
+// Copies that were deleted in dst are left alone.
+func Sync(src, dst Tracking, opts SyncOptions) (SyncResult, error) {
+	var result SyncResult
+	experimentIDs := opts.ExperimentIDs
+	if len(experimentIDs) == 0 {
+		exps, err := src.ExperimentsByName()
+		if err != nil {
+			return result, err
+		}
+		for _, exp := range exps {
+			experimentIDs = append(experimentIDs, exp.ID())
+		}
+	}
+	for _, id := range experimentIDs {
+		if err := syncExperiment(src, dst, id, &result); err != nil {
+			return result, fmt.Errorf("mlflow.Sync: error syncing experiment %s: %w", id, err)
+		}
+	}
+	return result, nil
+}
+
+func syncExperiment(src, dst Tracking, id string, result *SyncResult) error {
+	srcExp, err := src.GetExperiment(id)
+	if err != nil {
+		return err
+	}
+	info, err := srcExp.Info()
+	if err != nil {
+		return err
+	}
+	dstExp, err := dst.GetOrCreateExperimentWithName(info.Name)
+	if err != nil {
+		return err
+	}
+	for _, tag := range info.Tags {
+		if err := dstExp.SetTag(tag.Key, tag.Val); err != nil {
+			return err
+		}
+	}
+	pageToken := ""
+	for {
+		runs, nextPageToken, err := src.SearchRuns([]string{id}, "", nil, pageToken)
+		if err != nil {
+			return err
+		}
+		for _, run := range runs {
+			if err := syncRun(run, dst, dstExp, result); err != nil {
+				return fmt.Errorf("error syncing run %s: %w", run.ID(), err)
+			}
+		}
+		if nextPageToken == "" {
+			return nil
+		}
+		pageToken = nextPageToken
+	}
+}
+
+func syncRun(src Run, dst Tracking, dstExp Experiment, result *SyncResult) error {
+	info, err := src.Info()
+	if err != nil {
+		return err
+	}
+	tags, err := src.Tags()
+	if err != nil {
+		return err
+	}
+	params, err := src.Params()
+	if err != nil {
+		return err
+	}
+
+	filter := fmt.Sprintf("tags.`%s` = '%s'", SourceRunIDTagKey, info.ID)
+	existing, _, err := dst.SearchRuns([]string{dstExp.ID()}, filter, nil, "", WithViewType(ViewTypeAll), WithMaxResults(1))
+	if err != nil {
+		return err
+	}
+	var dstRun Run
+	var dstInfo RunInfo
+	if len(existing) == 0 {
+		dstRun, err = dstExp.CreateRun(info.Name, WithStartTime(info.StartTime),
+			WithTags(append(tags, Tag{Key: SourceRunIDTagKey, Val: info.ID})))
+		if err != nil {
+			return err
+		}
+		dstInfo = RunInfo{Status: RunStatusRunning}
+		// Everything has been copied except for params and metrics.
+		tags = nil
+		result.CreatedRunIDs = append(result.CreatedRunIDs, dstRun.ID())
+	} else {
+		dstRun = existing[0]
+		if dstInfo, err = dstRun.Info(); err != nil {
+			return err
+		}
+		if dstInfo.LifecycleStage == LifecycleStageDeleted {
+			return nil
+		}
+		if tags, err = missingTags(dstRun, tags); err != nil {
+			return err
+		}
+		if params, err = missingParams(dstRun, params); err != nil {
+			return err
+		}
+		result.UpdatedRunIDs = append(result.UpdatedRunIDs, dstRun.ID())
+	}
+
+	metrics, err := missingMetrics(src, dstRun, len(existing) == 0)
+	if err != nil {
+		return err
+	}
+	if len(metrics)+len(params)+len(tags) > 0 {
+		if err := dstRun.LogBatch(metrics, params, tags); err != nil {
+			return err
+		}
+		result.MetricsLogged += len(metrics)
+	}
+
+	terminated := info.Status.IsTerminated() &&
+		(info.Status != dstInfo.Status || !info.EndTime.Equal(dstInfo.EndTime))
+	if len(existing) == 0 || terminated {
+		if err := syncArtifacts(info.ArtifactURI, dstRun); err != nil {
+			return err
+		}
+	}
+	if terminated {
+		return dstRun.SetTerminated(info.Status, info.EndTime)
+	}
+	return nil
+}
+
+// missingTags returns the tags that are not set to the same value on run.
+func missingTags(run Run, tags []Tag) ([]Tag, error) {
+	existing, err := run.Tags()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(existing))
+	for _, tag := range existing {
+		values[tag.Key] = tag.Val
+	}
+	missing := make([]Tag, 0)
+	for _, tag := range tags {
+		if val, ok := values[tag.Key]; !ok || val != tag.Val {
+			missing = append(missing, tag)
+		}
+	}
+	return missing, nil
+}
+
+// missingParams returns the params that are not logged to run.
+// Params can't be changed once logged, so params with different values are returned too,
+// to report the conflict when they are logged.
+func missingParams(run Run, params []Param) ([]Param, error) {
+	existing, err := run.Params()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(existing))
+	for _, param := range existing {
+		values[param.Key] = param.Val
+	}
+	missing := make([]Param, 0)
+	for _, param := range params {
+		if val, ok := values[param.Key]; !ok || val != param.Val {
+			missing = append(missing, param)
+		}
+	}
+	return missing, nil
+}
+
+// loggedMetricID identifies a metric value, since the same value can be logged more than once.
+type loggedMetricID struct {
+	key       string
+	val       uint64
+	step      int64
+	timestamp int64
+}
+
+func newLoggedMetricID(m LoggedMetric) loggedMetricID {
+	val := m.Val
+	// NaN != NaN, and NaNs can have different bits.
+	if math.IsNaN(val) {
+		val = math.NaN()
+	}
+	return loggedMetricID{key: m.Key, val: math.Float64bits(val), step: m.Step, timestamp: m.Timestamp.UnixMilli()}
+}
+
+// missingMetrics returns the metric values logged to src that haven't been logged to dst.
+// If dstIsNew, dst is assumed to have no metrics.
+func missingMetrics(src, dst Run, dstIsNew bool) ([]LoggedMetric, error) {
+	latest, err := src.GetLatestMetrics()
+	if err != nil {
+		return nil, err
+	}
+	missing := make([]LoggedMetric, 0)
+	for _, m := range latest {
+		history, err := fullMetricHistory(src, m.Key)
+		if err != nil {
+			return nil, err
+		}
+		if dstIsNew {
+			missing = append(missing, history...)
+			continue
+		}
+		dstHistory, err := fullMetricHistory(dst, m.Key)
+		if err != nil {
+			return nil, err
+		}
+		// Count duplicates so that values logged more than once are copied as often.
+		logged := make(map[loggedMetricID]int, len(dstHistory))
+		for _, dm := range dstHistory {
+			logged[newLoggedMetricID(dm)]++
+		}
+		for _, sm := range history {
+			id := newLoggedMetricID(sm)
+			if logged[id] > 0 {
+				logged[id]--
+				continue
+			}
+			missing = append(missing, sm)
+		}
+	}
+	return missing, nil
+}
+
+func fullMetricHistory(run Run, key string) ([]LoggedMetric, error) {
+	history := make([]LoggedMetric, 0)
+	pageToken := ""
+	for {
+		page, nextPageToken, err := run.GetMetricHistory(key, pageToken)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, page...)
+		if nextPageToken == "" {
+			return history, nil
+		}
+		pageToken = nextPageToken
+	}
+}
+
+// syncArtifacts logs the files in the local directory at artifactURI to run.
+func syncArtifacts(artifactURI string, run Run) error {
+	parsed, err := url.Parse(artifactURI)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "file" && parsed.Scheme != "" {
+		return fmt.Errorf("copying artifacts from %s is not supported", artifactURI)
+	}
+	return logArtifactDir(filepath.FromSlash(parsed.Path), run)
+}
+
+// logArtifactDir logs the tree in dir to run, keeping paths relative to dir.
+// Does nothing if dir doesn't exist.
+func logArtifactDir(dir string, run Run) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// Log the whole tree at once where possible, e.g. so that DBFS uploads are batched.
+	if logger, ok := run.(artifactsLogger); ok {
+		return logger.logArtifacts(dir, "")
+	}
+	for _, entry := range entries {
+		if err := run.LogArtifact(filepath.Join(dir, entry.Name()), ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// artifactsLogger is implemented by runs that can log the contents of a directory with
+// [ArtifactRepo.LogArtifacts].
+type artifactsLogger interface {
+	logArtifacts(localDir, artifactPath string) error
+}
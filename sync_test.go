@@ -0,0 +1,106 @@
+package mlflow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	src, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	dst, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	srcExp, err := src.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	require.NoError(t, srcExp.SetTag("team", "vision"))
+	startTime := time.UnixMilli(1700000000000)
+	srcRun, err := srcExp.CreateRun("run0", WithStartTime(startTime))
+	require.NoError(t, err)
+	require.NoError(t, srcRun.LogBatch([]LoggedMetric{
+		{Key: "loss", Val: 2, Step: 0, Timestamp: startTime.Add(time.Second)},
+		{Key: "loss", Val: 1, Step: 1, Timestamp: startTime.Add(2 * time.Second)},
+		// Logging the same value twice is kept.
+		{Key: "loss", Val: 1, Step: 1, Timestamp: startTime.Add(2 * time.Second)},
+	}, []Param{{Key: "lr", Val: "0.1"}}, []Tag{{Key: "model", Val: "resnet"}}))
+
+	result, err := Sync(src, dst, SyncOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.CreatedRunIDs, 1)
+	assert.Empty(t, result.UpdatedRunIDs)
+	assert.Equal(t, 3, result.MetricsLogged)
+
+	dstExp, err := dst.GetOrCreateExperimentWithName("exp0")
+	require.NoError(t, err)
+	team, err := dstExp.GetTag("team")
+	require.NoError(t, err)
+	assert.Equal(t, "vision", team)
+	dstRun, err := dstExp.GetRun(result.CreatedRunIDs[0])
+	require.NoError(t, err)
+	info, err := dstRun.Info()
+	require.NoError(t, err)
+	assert.Equal(t, "run0", info.Name)
+	assert.Equal(t, RunStatusRunning, info.Status)
+	assert.True(t, startTime.Equal(info.StartTime))
+	sourceID, err := dstRun.GetTag(SourceRunIDTagKey)
+	require.NoError(t, err)
+	assert.Equal(t, srcRun.ID(), sourceID)
+	params, err := dstRun.Params()
+	require.NoError(t, err)
+	assert.Equal(t, []Param{{Key: "lr", Val: "0.1"}}, params)
+	model, err := dstRun.GetTag("model")
+	require.NoError(t, err)
+	assert.Equal(t, "resnet", model)
+	history, _, err := dstRun.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, int64(1), history[2].Step)
+	assert.True(t, startTime.Add(2*time.Second).Equal(history[2].Timestamp))
+
+	// Log more to the source run, then end it and sync again.
+	require.NoError(t, srcRun.LogMetric("loss", 0.5, 2))
+	require.NoError(t, srcRun.SetTag("model", "vit"))
+	artifactDir := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, os.MkdirAll(filepath.Join(artifactDir, "plots"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(artifactDir, "plots", "loss.svg"), []byte("<svg/>"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(artifactDir, "plots", "empty"), 0755))
+	require.NoError(t, srcRun.LogArtifact(filepath.Join(artifactDir, "plots"), ""))
+	endTime := startTime.Add(time.Hour)
+	require.NoError(t, srcRun.SetTerminated(RunStatusFailed, endTime))
+
+	result, err = Sync(src, dst, SyncOptions{ExperimentIDs: []string{srcExp.ID()}})
+	require.NoError(t, err)
+	assert.Empty(t, result.CreatedRunIDs)
+	assert.Equal(t, []string{dstRun.ID()}, result.UpdatedRunIDs)
+	assert.Equal(t, 1, result.MetricsLogged)
+
+	history, _, err = dstRun.GetMetricHistory("loss", "")
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, 0.5, history[3].Val)
+	model, err = dstRun.GetTag("model")
+	require.NoError(t, err)
+	assert.Equal(t, "vit", model)
+	info, err = dstRun.Info()
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusFailed, info.Status)
+	assert.True(t, endTime.Equal(info.EndTime))
+	artifact, err := os.ReadFile(filepath.Join(dstRun.(*fileRun).ArtifactDir(), "plots", "loss.svg"))
+	require.NoError(t, err)
+	assert.Equal(t, "<svg/>", string(artifact))
+	assert.DirExists(t, filepath.Join(dstRun.(*fileRun).ArtifactDir(), "plots", "empty"))
+
+	// Nothing changed, so syncing again is a no-op.
+	result, err = Sync(src, dst, SyncOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.CreatedRunIDs)
+	assert.Equal(t, 0, result.MetricsLogged)
+	runs, _, err := dst.SearchRuns([]string{dstExp.ID()}, "", nil, "")
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}